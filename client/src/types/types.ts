@@ -25,6 +25,13 @@ export type CommentSnippet = {
 		value: string
 	}
 	textOriginal: string
+	textDisplay: string
+	videoId: string
+	parentId?: string
+	canRate: boolean
+	likeCount: number
+	publishedAt: string
+	updatedAt: string
 }
 
 export type TopLevelComment = {
@@ -41,11 +48,16 @@ export type CommentThreadItem = {
 	id: string
 	snippet: {
 		channelId: string
+		videoId: string
 		topLevelComment: TopLevelComment
+		canReply: boolean
+		totalReplyCount: number
+		isPublic: boolean
 	}
 	replies: {
 		comments: ReplyComment[]
 	}
+	repliesTruncated: boolean
 }
 
 export type YTCommentThreadResponse = {
@@ -0,0 +1,34 @@
+package database
+
+import (
+	"github.com/google/uuid"
+)
+
+func GetNoteForUser(noteId string, userId uuid.UUID) (Note, error) {
+	var note Note
+	if err := DB.First(&note, "id = ? AND user_id = ?", noteId, userId).Error; err != nil {
+		return note, err
+	}
+
+	return note, openContent(&note)
+}
+
+func InsertAttachment(attachment *NoteAttachment) error {
+	return DB.Create(attachment).Error
+}
+
+func GetAttachment(id string) (NoteAttachment, error) {
+	var attachment NoteAttachment
+	err := DB.First(&attachment, "id = ?", id).Error
+	return attachment, err
+}
+
+func GetAttachmentsByNote(noteId uuid.UUID) ([]NoteAttachment, error) {
+	var attachments []NoteAttachment
+	err := DB.Where("note_id = ?", noteId).Order("created_at ASC").Find(&attachments).Error
+	return attachments, err
+}
+
+func DeleteAttachment(id uuid.UUID) error {
+	return DB.Delete(&NoteAttachment{}, "id = ?", id).Error
+}
@@ -0,0 +1,44 @@
+package database
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm/clause"
+)
+
+// UpsertAuthorListEntry adds an author or moves them to the other list
+func UpsertAuthorListEntry(entry *AuthorListEntry) error {
+	return DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "author_channel_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"list", "action", "author_display_name", "note", "updated_at"}),
+	}).Create(entry).Error
+}
+
+// GetAuthorList returns one list, or both when list is empty
+func GetAuthorList(userId uuid.UUID, list string) ([]AuthorListEntry, error) {
+	query := DB.Where("user_id = ?", userId)
+	if list != "" {
+		query = query.Where("list = ?", list)
+	}
+
+	var entries []AuthorListEntry
+	err := query.Order("created_at DESC").Find(&entries).Error
+	return entries, err
+}
+
+// GetAuthorLists returns both lists keyed by author channel id
+func GetAuthorLists(userId uuid.UUID) (map[string]AuthorListEntry, error) {
+	entries, err := GetAuthorList(userId, "")
+	if err != nil {
+		return nil, err
+	}
+
+	byAuthor := make(map[string]AuthorListEntry, len(entries))
+	for _, entry := range entries {
+		byAuthor[entry.AuthorChannelID] = entry
+	}
+	return byAuthor, nil
+}
+
+func DeleteAuthorListEntry(userId uuid.UUID, authorChannelId string) error {
+	return DB.Where("user_id = ? AND author_channel_id = ?", userId, authorChannelId).Delete(&AuthorListEntry{}).Error
+}
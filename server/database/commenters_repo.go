@@ -0,0 +1,102 @@
+package database
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"gorm.io/gorm/clause"
+)
+
+// AuthorStats aggregates a commenter's stored comments on one channel
+type AuthorStats struct {
+	Comments        int        `json:"comments"`
+	TopLevel        int        `json:"topLevel"`
+	Replies         int        `json:"replies"`
+	Videos          int        `json:"videos"`
+	LikesReceived   int        `json:"likesReceived"`
+	RepliesReceived int        `json:"repliesReceived"`
+	FirstSeen       *time.Time `json:"firstSeen"` // nil without stored comments
+	LastSeen        *time.Time `json:"lastSeen"`
+}
+
+type AuthorVideoCount struct {
+	VideoID  string    `json:"videoId"`
+	Comments int       `json:"comments"`
+	LastSeen time.Time `json:"lastSeen"`
+}
+
+func GetAuthorStats(channelId string, authorChannelId string) (AuthorStats, error) {
+	var stats AuthorStats
+	err := DB.Model(&Comment{}).
+		Select(`COUNT(*) AS comments,
+			COUNT(*) FILTER (WHERE parent_id = '') AS top_level,
+			COUNT(*) FILTER (WHERE parent_id <> '') AS replies,
+			COUNT(DISTINCT video_id) AS videos,
+			COALESCE(SUM(like_count), 0) AS likes_received,
+			MIN(published_at) AS first_seen,
+			MAX(published_at) AS last_seen`).
+		Where("channel_id = ? AND author_channel_id = ?", channelId, authorChannelId).
+		Scan(&stats).Error
+	if err != nil {
+		return stats, err
+	}
+
+	// replies other people left under threads this author started, counted
+	// from stored replies since total_reply_count includes the author's own
+	err = DB.Table("comments AS replies").
+		Select("COUNT(*)").
+		Joins("JOIN comments AS top ON top.thread_id = replies.thread_id AND top.parent_id = ''").
+		Where("top.channel_id = ? AND top.author_channel_id = ?", channelId, authorChannelId).
+		Where("replies.parent_id <> '' AND replies.author_channel_id <> ?", authorChannelId).
+		Scan(&stats.RepliesReceived).Error
+
+	return stats, err
+}
+
+// GetAuthorVideos counts a commenter's comments per video, most active first
+func GetAuthorVideos(channelId string, authorChannelId string) ([]AuthorVideoCount, error) {
+	var videos []AuthorVideoCount
+	err := DB.Model(&Comment{}).
+		Select("video_id, COUNT(*) AS comments, MAX(published_at) AS last_seen").
+		Where("channel_id = ? AND author_channel_id = ?", channelId, authorChannelId).
+		Group("video_id").
+		Order("comments DESC, last_seen DESC").
+		Scan(&videos).Error
+	return videos, err
+}
+
+// GetAuthorComments returns a commenter's newest comments on a channel
+func GetAuthorComments(channelId string, authorChannelId string, limit int) ([]Comment, error) {
+	var comments []Comment
+	err := DB.Where("channel_id = ? AND author_channel_id = ?", channelId, authorChannelId).
+		Order("published_at DESC").
+		Limit(limit).
+		Find(&comments).Error
+	return comments, err
+}
+
+func GetCommenterProfile(userId uuid.UUID, authorChannelId string) (CommenterProfile, error) {
+	var profile CommenterProfile
+	err := DB.First(&profile, "user_id = ? AND author_channel_id = ?", userId, authorChannelId).Error
+	return profile, err
+}
+
+func SaveCommenterProfile(profile *CommenterProfile) error {
+	return DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "author_channel_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"note", "labels", "updated_at"}),
+	}).Create(profile).Error
+}
+
+// GetCommenterProfiles lists the user's annotated commenters, optionally with a label
+func GetCommenterProfiles(userId uuid.UUID, label string) ([]CommenterProfile, error) {
+	query := DB.Where("user_id = ?", userId)
+	if label != "" {
+		query = query.Where("labels @> ?", pq.StringArray{label})
+	}
+
+	var profiles []CommenterProfile
+	err := query.Order("updated_at DESC").Find(&profiles).Error
+	return profiles, err
+}
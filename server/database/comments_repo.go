@@ -0,0 +1,229 @@
+package database
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CommentQuery struct {
+	VideoID     string
+	SearchTerms string
+	SortByLikes bool
+	Offset      int
+	Limit       int
+}
+
+// UpsertThread stores a thread with its top level comment and replies
+func UpsertThread(thread *CommentThread, comments []Comment) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(thread).Error; err != nil {
+			return err
+		}
+
+		if len(comments) == 0 {
+			return nil
+		}
+
+		return tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(&comments).Error
+	})
+}
+
+// ThreadState is what incremental sync compares to spot changed threads
+type ThreadState struct {
+	ID              string
+	TotalReplyCount int
+	EditedAt        time.Time
+}
+
+// GetThreadStates returns the stored threads of a video keyed by id
+func GetThreadStates(videoId string) (map[string]ThreadState, error) {
+	var rows []ThreadState
+	err := DB.Model(&CommentThread{}).
+		Select("comment_threads.id, comment_threads.total_reply_count, comments.edited_at").
+		Joins("JOIN comments ON comments.id = comment_threads.top_level_comment_id").
+		Where("comment_threads.video_id = ?", videoId).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	states := make(map[string]ThreadState, len(rows))
+	for _, row := range rows {
+		states[row.ID] = row
+	}
+	return states, nil
+}
+
+/*
+DeleteMissingThreads
+- Removes threads (and their comments) of a video that YouTube no longer returns
+- Only valid after a full sync saw every thread
+*/
+func DeleteMissingThreads(videoId string, seen []string) (int64, error) {
+	var removed int64
+
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		threads := tx.Where("video_id = ?", videoId)
+		comments := tx.Where("video_id = ?", videoId)
+		if len(seen) > 0 {
+			threads = threads.Where("id NOT IN ?", seen)
+			comments = comments.Where("thread_id NOT IN ?", seen)
+		}
+
+		if err := comments.Delete(&Comment{}).Error; err != nil {
+			return err
+		}
+
+		res := threads.Delete(&CommentThread{})
+		removed = res.RowsAffected
+		return res.Error
+	})
+
+	return removed, err
+}
+
+// DeleteMissingReplies removes replies of a thread that are no longer on YouTube
+func DeleteMissingReplies(threadId string, seen []string) (int64, error) {
+	query := DB.Where("thread_id = ? AND parent_id <> ''", threadId)
+	if len(seen) > 0 {
+		query = query.Where("id NOT IN ?", seen)
+	}
+
+	res := query.Delete(&Comment{})
+	return res.RowsAffected, res.Error
+}
+
+func GetVideoSync(videoId string) (VideoSync, error) {
+	var sync VideoSync
+	err := DB.First(&sync, "video_id = ?", videoId).Error
+	return sync, err
+}
+
+func SaveVideoSync(sync *VideoSync) error {
+	return DB.Save(sync).Error
+}
+
+/*
+ListThreads
+- Pages through the stored threads of a video, newest first or by likes
+- Returns the threads in order with their comments, top level first
+*/
+func ListThreads(q CommentQuery) ([]CommentThread, map[string][]Comment, error) {
+	query := DB.Model(&Comment{}).Where("video_id = ? AND parent_id = ''", q.VideoID)
+
+	if q.SearchTerms != "" {
+		like := "%" + escapeLike(q.SearchTerms) + "%"
+		query = query.Where(
+			"text_original ILIKE ? OR EXISTS (?)",
+			like,
+			DB.Table("comments AS replies").Select("1").
+				Where("replies.thread_id = comments.thread_id AND replies.text_original ILIKE ?", like),
+		)
+	}
+
+	if q.SortByLikes {
+		query = query.Order("like_count DESC")
+	}
+	query = query.Order("published_at DESC")
+
+	var threadIds []string
+	err := query.Offset(q.Offset).Limit(q.Limit).Pluck("thread_id", &threadIds).Error
+	if err != nil || len(threadIds) == 0 {
+		return nil, nil, err
+	}
+
+	var threads []CommentThread
+	if err := DB.Where("id IN ?", threadIds).Find(&threads).Error; err != nil {
+		return nil, nil, err
+	}
+
+	var comments []Comment
+	err = DB.Where("thread_id IN ?", threadIds).
+		Order("parent_id ASC, published_at ASC").
+		Find(&comments).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	byId := make(map[string]CommentThread, len(threads))
+	for _, t := range threads {
+		byId[t.ID] = t
+	}
+
+	ordered := make([]CommentThread, 0, len(threadIds))
+	for _, id := range threadIds {
+		if t, ok := byId[id]; ok {
+			ordered = append(ordered, t)
+		}
+	}
+
+	grouped := make(map[string][]Comment, len(threadIds))
+	for _, c := range comments {
+		grouped[c.ThreadID] = append(grouped[c.ThreadID], c)
+	}
+
+	return ordered, grouped, nil
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// escapeLike makes % and _ in user input match literally, backslash is
+// postgres' default LIKE escape
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
+
+// UpdateCommentText mirrors an edit made through the dashboard, a no-op for unsynced comments
+func UpdateCommentText(id string, text string, editedAt time.Time) error {
+	return DB.Model(&Comment{}).Where("id = ?", id).Updates(map[string]any{
+		"text_original": text,
+		"text_display":  text,
+		"edited_at":     editedAt,
+	}).Error
+}
+
+// DeleteStoredComment drops a deleted comment (and its replies when top level) from the local store
+func DeleteStoredComment(id string) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ? OR thread_id = ?", id, id).Delete(&Comment{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", id).Delete(&CommentThread{}).Error
+	})
+}
+
+// GetRecentComments returns stored comments of a channel, newest first,
+// optionally limited to one video and to comments published since a time
+func GetRecentComments(channelId string, videoId string, since time.Time, limit int) ([]Comment, error) {
+	query := DB.Where("channel_id = ?", channelId)
+	if videoId != "" {
+		query = query.Where("video_id = ?", videoId)
+	}
+	if !since.IsZero() {
+		query = query.Where("published_at >= ?", since)
+	}
+
+	var comments []Comment
+	err := query.Order("published_at DESC").Limit(limit).Find(&comments).Error
+	return comments, err
+}
+
+/*
+GetSpamCorpus
+- Returns id and text of the video's stored comments and of the channel's comments published since
+- Copy-paste spam waves hit many videos at once, so the channel's recent comments count too
+- Newest first, at most limit rows
+*/
+func GetSpamCorpus(channelId string, videoId string, since time.Time, limit int) ([]Comment, error) {
+	var comments []Comment
+	err := DB.Select("id", "text_original").
+		Where("video_id = ?", videoId).
+		Or("channel_id = ? AND published_at >= ?", channelId, since).
+		Order("published_at DESC").
+		Limit(limit).
+		Find(&comments).Error
+	return comments, err
+}
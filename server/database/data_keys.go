@@ -0,0 +1,149 @@
+package database
+
+import (
+	"encoding/base64"
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"yt_dashboard.com/utils"
+)
+
+var (
+	dataKeys   = make(map[uuid.UUID][]byte)
+	dataKeysMu sync.RWMutex
+)
+
+// NotesEncryptionEnabled reports whether note content and attachments are
+// encrypted at rest, set NOTES_ENCRYPTION=true to turn it on
+func NotesEncryptionEnabled() bool {
+	return os.Getenv("NOTES_ENCRYPTION") == "true"
+}
+
+/*
+getDataKey
+- Returns the unwrapped data key of a user, creating one on first use
+- Unwrapped keys are cached in memory, the DB only ever sees wrapped ones
+*/
+func getDataKey(userId uuid.UUID) ([]byte, error) {
+	dataKeysMu.RLock()
+	key, ok := dataKeys[userId]
+	dataKeysMu.RUnlock()
+	if ok {
+		return key, nil
+	}
+
+	var dataKey DataKey
+	err := DB.First(&dataKey, "user_id = ?", userId).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		key, err = createDataKey(userId)
+	case err == nil:
+		key, err = unwrapDataKey(dataKey)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	dataKeysMu.Lock()
+	dataKeys[userId] = key
+	dataKeysMu.Unlock()
+
+	return key, nil
+}
+
+func createDataKey(userId uuid.UUID) ([]byte, error) {
+	key, err := utils.GenerateDataKey()
+	if err != nil {
+		return nil, err
+	}
+
+	wrapped, err := utils.Encrypt(base64.StdEncoding.EncodeToString(key))
+	if err != nil {
+		return nil, err
+	}
+
+	dataKey := DataKey{
+		UserID:     userId,
+		WrappedKey: wrapped,
+		CreatedAt:  time.Now(),
+	}
+
+	// two requests may race to create the key, the loser uses the winner's
+	if err := DB.Create(&dataKey).Error; err != nil {
+		if err := DB.First(&dataKey, "user_id = ?", userId).Error; err != nil {
+			return nil, err
+		}
+		return unwrapDataKey(dataKey)
+	}
+
+	return key, nil
+}
+
+func unwrapDataKey(dataKey DataKey) ([]byte, error) {
+	keyB64, err := utils.Decrypt(dataKey.WrappedKey)
+	if err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(keyB64)
+}
+
+func SealForUser(userId uuid.UUID, plain []byte) ([]byte, error) {
+	key, err := getDataKey(userId)
+	if err != nil {
+		return nil, err
+	}
+	return utils.EncryptBytes(key, plain)
+}
+
+func OpenForUser(userId uuid.UUID, data []byte) ([]byte, error) {
+	key, err := getDataKey(userId)
+	if err != nil {
+		return nil, err
+	}
+	return utils.DecryptBytes(key, data)
+}
+
+// sealContent encrypts note.Content in place with the owner's data key
+func sealContent(note *Note) error {
+	cipherText, err := SealForUser(note.UserID, []byte(note.Content))
+	if err != nil {
+		return err
+	}
+
+	note.Content = base64.StdEncoding.EncodeToString(cipherText)
+	note.Encrypted = true
+	return nil
+}
+
+// openContent reverses sealContent, plaintext notes are left alone
+func openContent(note *Note) error {
+	if !note.Encrypted {
+		return nil
+	}
+
+	data, err := base64.StdEncoding.DecodeString(note.Content)
+	if err != nil {
+		return err
+	}
+
+	plain, err := OpenForUser(note.UserID, data)
+	if err != nil {
+		return err
+	}
+
+	note.Content = string(plain)
+	return nil
+}
+
+func openNotes(notes []Note) error {
+	for i := range notes {
+		if err := openContent(&notes[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -17,7 +17,30 @@ func DbInit() error {
 		return err
 	}
 
-	if err := db.AutoMigrate(&User{}, &Token{}, &Note{}); err != nil {
+	err = db.AutoMigrate(
+		&User{},
+		&Token{},
+		&DataKey{},
+		&Note{},
+		&NoteAttachment{},
+		&NoteShare{},
+		&NoteTemplate{},
+		&CommentThread{},
+		&Comment{},
+		&VideoSync{},
+		&CommentTriage{},
+		&ReplyTemplate{},
+		&PendingDeletion{},
+		&CommentJob{},
+		&IdempotencyRecord{},
+		&CommentRule{},
+		&CommentRuleHit{},
+		&CommentTag{},
+		&AuthorListEntry{},
+		&CommenterProfile{},
+		&Giveaway{},
+	)
+	if err != nil {
 		return err
 	}
 
@@ -0,0 +1,97 @@
+package database
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrDeletionNotPending = errors.New("deletion already executed or cancelled")
+
+func InsertPendingDeletion(deletion *PendingDeletion) error {
+	return DB.Create(deletion).Error
+}
+
+// CancelDeletion undoes a queued deletion as long as the worker hasn't picked it up
+func CancelDeletion(id string, userId uuid.UUID) error {
+	res := DB.Model(&PendingDeletion{}).
+		Where("id = ? AND user_id = ? AND status = ?", id, userId, DeletionPending).
+		Update("status", DeletionCancelled)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrDeletionNotPending
+	}
+	return nil
+}
+
+func GetPendingDeletions(userId uuid.UUID) ([]PendingDeletion, error) {
+	var deletions []PendingDeletion
+	err := DB.Where("user_id = ? AND status = ?", userId, DeletionPending).
+		Order("execute_at ASC").
+		Find(&deletions).Error
+	return deletions, err
+}
+
+// GetPendingDeletionsFor returns queued deletions of the given comments keyed by comment id
+func GetPendingDeletionsFor(commentIds []string) (map[string]PendingDeletion, error) {
+	pending := make(map[string]PendingDeletion)
+	if len(commentIds) == 0 {
+		return pending, nil
+	}
+
+	var rows []PendingDeletion
+	err := DB.Where("comment_id IN ? AND status = ?", commentIds, DeletionPending).Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		pending[row.CommentID] = row
+	}
+	return pending, nil
+}
+
+/*
+ClaimDueDeletions
+- Moves deletions whose undo window is over from pending to running
+- Running ones claimed more than lease ago are claimed again, their worker died before finishing
+- The status check in the update keeps an undo and the worker from both winning
+*/
+func ClaimDueDeletions(now time.Time, lease time.Duration) ([]PendingDeletion, error) {
+	claimable := DB.Where("status = ? AND execute_at <= ?", DeletionPending, now).
+		Or("status = ? AND (claimed_at IS NULL OR claimed_at < ?)", DeletionRunning, now.Add(-lease))
+
+	var due []PendingDeletion
+	if err := DB.Where(claimable).Find(&due).Error; err != nil {
+		return nil, err
+	}
+
+	claimed := due[:0]
+	for _, deletion := range due {
+		res := DB.Model(&PendingDeletion{}).
+			Where("id = ?", deletion.ID).
+			Where(claimable).
+			Updates(map[string]any{"status": DeletionRunning, "claimed_at": now})
+		if res.Error != nil {
+			return claimed, res.Error
+		}
+		if res.RowsAffected == 1 {
+			deletion.Status = DeletionRunning
+			deletion.ClaimedAt = &now
+			claimed = append(claimed, deletion)
+		}
+	}
+
+	return claimed, nil
+}
+
+func FinishDeletion(id uuid.UUID, err error) error {
+	updates := map[string]any{"status": DeletionDone}
+	if err != nil {
+		updates = map[string]any{"status": DeletionFailed, "error": err.Error()}
+	}
+	return DB.Model(&PendingDeletion{}).Where("id = ?", id).Updates(updates).Error
+}
@@ -0,0 +1,126 @@
+package database
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/google/uuid"
+
+	"yt_dashboard.com/storage"
+)
+
+const encryptionBatchSize = 100
+
+/*
+EncryptExistingNotes
+- One off migration for notes written before NOTES_ENCRYPTION was enabled
+- Safe to rerun, only rows with encrypted = false are touched
+*/
+func EncryptExistingNotes() (int, error) {
+	total := 0
+
+	for {
+		var notes []Note
+		err := DB.Where("encrypted = ?", false).Limit(encryptionBatchSize).Find(&notes).Error
+		if err != nil {
+			return total, err
+		}
+		if len(notes) == 0 {
+			return total, nil
+		}
+
+		for i := range notes {
+			if err := sealContent(&notes[i]); err != nil {
+				return total, err
+			}
+
+			err := DB.Model(&notes[i]).UpdateColumns(map[string]any{
+				"content":   notes[i].Content,
+				"encrypted": true,
+			}).Error
+			if err != nil {
+				return total, err
+			}
+			total++
+		}
+	}
+}
+
+/*
+EncryptExistingAttachments
+- Blob counterpart of EncryptExistingNotes
+- The sealed copy goes to a new key, the row switches to it in the same update that sets encrypted
+- A failure at any step leaves the row pointing at readable content, so the run can be repeated
+- Missing blobs are logged and skipped
+*/
+func EncryptExistingAttachments(store storage.BlobStore) (int, error) {
+	total := 0
+	var last uuid.UUID
+
+	for {
+		var attachments []NoteAttachment
+		err := DB.Where("encrypted = ? AND id > ?", false, last).
+			Order("id ASC").
+			Limit(encryptionBatchSize).
+			Find(&attachments).Error
+		if err != nil {
+			return total, err
+		}
+		if len(attachments) == 0 {
+			return total, nil
+		}
+
+		for _, attachment := range attachments {
+			last = attachment.ID
+
+			sealedKey, err := encryptBlob(store, attachment)
+			if errors.Is(err, storage.ErrBlobNotFound) {
+				fmt.Println("Skipping attachment with missing blob:", attachment.ID)
+				continue
+			}
+			if err != nil {
+				return total, err
+			}
+
+			err = DB.Model(&attachment).UpdateColumns(map[string]any{
+				"storage_key": sealedKey,
+				"encrypted":   true,
+			}).Error
+			if err != nil {
+				store.Delete(sealedKey)
+				return total, err
+			}
+
+			// the plaintext copy is unreferenced now, a leftover is only wasted space
+			if err := store.Delete(attachment.StorageKey); err != nil {
+				fmt.Println("Cant delete plaintext blob:", attachment.StorageKey, err)
+			}
+			total++
+		}
+	}
+}
+
+// encryptBlob writes a sealed copy of the attachment next to the original
+// and returns its key
+func encryptBlob(store storage.BlobStore, attachment NoteAttachment) (string, error) {
+	blob, err := store.Get(attachment.StorageKey)
+	if err != nil {
+		return "", err
+	}
+
+	plain, err := io.ReadAll(blob)
+	blob.Close()
+	if err != nil {
+		return "", err
+	}
+
+	sealed, err := SealForUser(attachment.UserID, plain)
+	if err != nil {
+		return "", err
+	}
+
+	sealedKey := attachment.StorageKey + ".sealed"
+	return sealedKey, store.Put(sealedKey, bytes.NewReader(sealed))
+}
@@ -0,0 +1,45 @@
+package database
+
+import (
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+var ErrGiveawayDrawn = errors.New("giveaway winners were already drawn")
+
+func InsertGiveaway(giveaway *Giveaway) error {
+	return DB.Create(giveaway).Error
+}
+
+func GetGiveaway(id string, userId uuid.UUID) (Giveaway, error) {
+	var giveaway Giveaway
+	err := DB.First(&giveaway, "id = ? AND user_id = ?", id, userId).Error
+	return giveaway, err
+}
+
+func GetGiveaways(userId uuid.UUID, videoId string) ([]Giveaway, error) {
+	query := DB.Where("user_id = ?", userId)
+	if videoId != "" {
+		query = query.Where("video_id = ?", videoId)
+	}
+
+	var giveaways []Giveaway
+	err := query.Order("created_at DESC").Find(&giveaways).Error
+	return giveaways, err
+}
+
+// SaveGiveawayDraw stores the entries and winners, a giveaway is drawn once
+func SaveGiveawayDraw(giveaway *Giveaway) error {
+	res := DB.Model(giveaway).
+		Where("drawn_at IS NULL").
+		Select("Entries", "EntriesHash", "EntryIDs", "WinnerIDs", "DrawnAt").
+		Updates(giveaway)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrGiveawayDrawn
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package database
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+/*
+ReserveIdempotencyKey
+- Returns (nil, nil) when the key is new, the caller then owns it
+- Returns the stored record when the key was used within ttl
+- Expired records are replaced, so are records of the same request still in progress after lease
+*/
+func ReserveIdempotencyKey(userId uuid.UUID, key string, hash string, ttl time.Duration, lease time.Duration) (*IdempotencyRecord, error) {
+	var existing IdempotencyRecord
+	err := DB.First(&existing, "user_id = ? AND key = ?", userId, key).Error
+
+	if err == nil && existing.Status == 0 && existing.RequestHash == hash && time.Since(existing.CreatedAt) > lease {
+		// the created_at check lets only one of several retries take it over
+		res := DB.Model(&IdempotencyRecord{}).
+			Where("user_id = ? AND key = ? AND status = 0 AND created_at = ?", userId, key, existing.CreatedAt).
+			Update("created_at", time.Now())
+		if res.Error != nil {
+			return nil, res.Error
+		}
+		if res.RowsAffected == 1 {
+			return nil, nil
+		}
+		return &existing, nil
+	}
+
+	if err == nil && time.Since(existing.CreatedAt) < ttl {
+		return &existing, nil
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	// clear out this user's expired keys while we are here
+	DB.Where("user_id = ? AND created_at < ?", userId, time.Now().Add(-ttl)).Delete(&IdempotencyRecord{})
+
+	record := IdempotencyRecord{
+		UserID:      userId,
+		Key:         key,
+		RequestHash: hash,
+		CreatedAt:   time.Now(),
+	}
+
+	// a concurrent request with the same key may have won the insert
+	if err := DB.Create(&record).Error; err != nil {
+		if err := DB.First(&existing, "user_id = ? AND key = ?", userId, key).Error; err != nil {
+			return nil, err
+		}
+		return &existing, nil
+	}
+
+	return nil, nil
+}
+
+func CompleteIdempotencyKey(userId uuid.UUID, key string, status int, contentType string, body []byte) error {
+	return DB.Model(&IdempotencyRecord{}).
+		Where("user_id = ? AND key = ?", userId, key).
+		Updates(map[string]any{"status": status, "content_type": contentType, "body": body}).Error
+}
+
+// ReleaseIdempotencyKey forgets a key so the request can be retried
+func ReleaseIdempotencyKey(userId uuid.UUID, key string) error {
+	return DB.Where("user_id = ? AND key = ?", userId, key).Delete(&IdempotencyRecord{}).Error
+}
@@ -0,0 +1,100 @@
+package database
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const MaxJobAttempts = 5
+
+var ErrJobNotPending = errors.New("job already running, finished or cancelled")
+
+func InsertCommentJob(job *CommentJob) error {
+	return DB.Create(job).Error
+}
+
+// GetCommentJobs lists a user's jobs, optionally only the ones in status
+func GetCommentJobs(userId uuid.UUID, status string) ([]CommentJob, error) {
+	var jobs []CommentJob
+
+	query := DB.Where("user_id = ?", userId).Order("scheduled_at ASC")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	err := query.Find(&jobs).Error
+	return jobs, err
+}
+
+func CancelCommentJob(id string, userId uuid.UUID) error {
+	res := DB.Model(&CommentJob{}).
+		Where("id = ? AND user_id = ? AND status = ?", id, userId, JobPending).
+		Update("status", JobCancelled)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrJobNotPending
+	}
+	return nil
+}
+
+/*
+ClaimDueJobs
+- Moves due jobs from pending to running, see ClaimDueDeletions
+- Running jobs claimed more than lease ago are claimed again as unconfirmed, their runner died mid attempt
+*/
+func ClaimDueJobs(now time.Time, lease time.Duration) ([]CommentJob, error) {
+	claimable := DB.Where("status = ? AND next_attempt_at <= ?", JobPending, now).
+		Or("status = ? AND (claimed_at IS NULL OR claimed_at < ?)", JobRunning, now.Add(-lease))
+
+	var due []CommentJob
+	if err := DB.Where(claimable).Find(&due).Error; err != nil {
+		return nil, err
+	}
+
+	claimed := due[:0]
+	for _, job := range due {
+		updates := map[string]any{"status": JobRunning, "attempts": job.Attempts + 1, "claimed_at": now}
+		if job.Status == JobRunning {
+			job.Unconfirmed = true
+			updates["unconfirmed"] = true
+		}
+
+		res := DB.Model(&CommentJob{}).Where("id = ?", job.ID).Where(claimable).Updates(updates)
+		if res.Error != nil {
+			return claimed, res.Error
+		}
+		if res.RowsAffected == 1 {
+			job.Status = JobRunning
+			job.Attempts++
+			job.ClaimedAt = &now
+			claimed = append(claimed, job)
+		}
+	}
+
+	return claimed, nil
+}
+
+/*
+FinishCommentJob
+- Success marks the job done
+- Retryable failures go back to pending with exponential backoff until MaxJobAttempts, others fail right away
+- job.Unconfirmed is saved so the next attempt knows to check YouTube first
+*/
+func FinishCommentJob(job CommentJob, err error, retry bool) error {
+	updates := map[string]any{"status": JobDone, "last_error": "", "unconfirmed": false}
+
+	if err != nil {
+		updates = map[string]any{"status": JobFailed, "last_error": err.Error(), "unconfirmed": job.Unconfirmed}
+		if retry && job.Attempts < MaxJobAttempts {
+			backoff := time.Minute << (job.Attempts - 1)
+			updates["status"] = JobPending
+			updates["next_attempt_at"] = time.Now().Add(backoff)
+		}
+	}
+
+	return DB.Model(&CommentJob{}).Where("id = ?", job.ID).Updates(updates).Error
+}
@@ -0,0 +1,73 @@
+package database
+
+import (
+	"time"
+)
+
+type LeaderboardQuery struct {
+	ChannelID string
+	VideoID   string
+	Since     time.Time // zero for all time
+	SortBy    string    // comments, likes or videos
+	Limit     int
+}
+
+type LeaderboardEntry struct {
+	Rank                  int       `json:"rank" gorm:"-"`
+	AuthorChannelID       string    `json:"authorChannelId"`
+	AuthorDisplayName     string    `json:"authorDisplayName"`
+	AuthorProfileImageUrl string    `json:"authorProfileImageUrl"`
+	Comments              int       `json:"comments"`
+	Likes                 int       `json:"likes"`
+	Videos                int       `json:"videos"`
+	LastSeen              time.Time `json:"lastSeen"`
+}
+
+var leaderboardOrder = map[string]string{
+	"comments": "comments DESC, likes DESC",
+	"likes":    "likes DESC, comments DESC",
+	"videos":   "videos DESC, comments DESC",
+}
+
+/*
+GetLeaderboard
+- Ranks the commenters of a channel's stored comments, the channel itself is left out
+- Names and avatars are taken from each author's newest comment
+*/
+func GetLeaderboard(q LeaderboardQuery) ([]LeaderboardEntry, error) {
+	query := DB.Model(&Comment{}).
+		Select(`author_channel_id,
+			(ARRAY_AGG(author_display_name ORDER BY published_at DESC))[1] AS author_display_name,
+			(ARRAY_AGG(author_profile_image_url ORDER BY published_at DESC))[1] AS author_profile_image_url,
+			COUNT(*) AS comments,
+			COALESCE(SUM(like_count), 0) AS likes,
+			COUNT(DISTINCT video_id) AS videos,
+			MAX(published_at) AS last_seen`).
+		Where("channel_id = ? AND author_channel_id <> '' AND author_channel_id <> channel_id", q.ChannelID)
+
+	if q.VideoID != "" {
+		query = query.Where("video_id = ?", q.VideoID)
+	}
+	if !q.Since.IsZero() {
+		query = query.Where("published_at >= ?", q.Since)
+	}
+
+	order, ok := leaderboardOrder[q.SortBy]
+	if !ok {
+		order = leaderboardOrder["comments"]
+	}
+
+	var entries []LeaderboardEntry
+	err := query.Group("author_channel_id").
+		Order(order + ", last_seen DESC").
+		Limit(q.Limit).
+		Scan(&entries).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range entries {
+		entries[i].Rank = i + 1
+	}
+	return entries, nil
+}
@@ -21,12 +21,293 @@ type Token struct {
 	CreatedAt       time.Time
 }
 
+// DataKey is a per-user AES key wrapped with the master key (utils.Encrypt)
+type DataKey struct {
+	UserID     uuid.UUID `gorm:"type:uuid;primaryKey"`
+	WrappedKey string    `gorm:"not null"`
+	CreatedAt  time.Time
+}
+
 type Note struct {
+	ID           uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	UserID       uuid.UUID `gorm:"type:uuid;index"`
+	VideoID      string    `gorm:"index"`
+	Content      string
+	Tags         pq.StringArray   `gorm:"type:text[]"`
+	Attachments  []NoteAttachment `gorm:"foreignKey:NoteID;constraint:OnDelete:CASCADE"`
+	LastEditedBy uuid.UUID        `gorm:"type:uuid"`
+	Encrypted    bool             `gorm:"default:false" json:"-"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+type NoteAttachment struct {
+	ID         uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	NoteID     uuid.UUID `gorm:"type:uuid;index;not null"`
+	UserID     uuid.UUID `gorm:"type:uuid;index"`
+	FileName   string    `gorm:"not null"`
+	MimeType   string    `gorm:"not null"`
+	Size       int64
+	StorageKey string `gorm:"not null"`
+	Encrypted  bool   `gorm:"default:false" json:"-"`
+	CreatedAt  time.Time
+}
+
+const (
+	ShareRead  = "read"
+	ShareWrite = "write"
+)
+
+// NoteShare grants SharedWithID access to a single note (NoteID set) or to
+// every note the owner keeps for VideoID (NoteID nil). A collaborator holds
+// at most one share per note and one per video.
+type NoteShare struct {
+	ID           uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	OwnerID      uuid.UUID  `gorm:"type:uuid;index;not null;uniqueIndex:idx_note_share_video,where:note_id IS NULL"`
+	SharedWithID uuid.UUID  `gorm:"type:uuid;index;not null;uniqueIndex:idx_note_share_note,where:note_id IS NOT NULL;uniqueIndex:idx_note_share_video"`
+	NoteID       *uuid.UUID `gorm:"type:uuid;index;uniqueIndex:idx_note_share_note"`
+	VideoID      string     `gorm:"index;uniqueIndex:idx_note_share_video"`
+	Access       string     `gorm:"not null"`
+	CreatedAt    time.Time
+}
+
+type NoteTemplate struct {
+	ID        uuid.UUID      `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	UserID    uuid.UUID      `gorm:"type:uuid;index"`
+	Name      string         `gorm:"not null"`
+	Content   string         `gorm:"not null"`
+	Tags      pq.StringArray `gorm:"type:text[]"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// CommentThread and Comment mirror YouTube comment data for the videos we
+// sync, ids are YouTube's so rows are shared by everyone managing a channel
+type CommentThread struct {
+	ID                string `gorm:"primaryKey"`
+	ChannelID         string `gorm:"index"`
+	VideoID           string `gorm:"index"`
+	TopLevelCommentID string
+	CanReply          bool
+	IsPublic          bool
+	TotalReplyCount   int
+	SyncedAt          time.Time
+}
+
+type Comment struct {
+	ID                    string `gorm:"primaryKey"`
+	ThreadID              string `gorm:"index"`
+	ParentID              string `gorm:"index"` // empty for top level comments
+	ChannelID             string `gorm:"index"`
+	VideoID               string `gorm:"index"`
+	AuthorDisplayName     string
+	AuthorProfileImageUrl string
+	AuthorChannelUrl      string
+	AuthorChannelID       string `gorm:"index"`
+	TextOriginal          string
+	TextDisplay           string
+	CanRate               bool
+	LikeCount             int
+	PublishedAt           time.Time `gorm:"index"`
+	EditedAt              time.Time // YouTube's updatedAt, UpdatedAt is gorm's
+	SyncedAt              time.Time
+}
+
+type VideoSync struct {
+	VideoID        string `gorm:"primaryKey"`
+	ChannelID      string `gorm:"index"`
+	LastSyncedAt   time.Time
+	LastFullSyncAt time.Time
+	// the video's commentCount statistic at the last sync
+	CommentCount int
+}
+
+const (
+	TriageNew        = "new"
+	TriageInProgress = "in_progress"
+	TriageAnswered   = "answered"
+	TriageIgnored    = "ignored"
+)
+
+// CommentTriage is local moderation state for a YouTube comment, comments
+// without a row are TriageNew
+type CommentTriage struct {
+	CommentID  string     `gorm:"primaryKey" json:"commentId"`
+	Status     string     `gorm:"not null;index" json:"status"`
+	AssigneeID *uuid.UUID `gorm:"type:uuid;index" json:"assigneeId"`
+	UpdatedBy  uuid.UUID  `gorm:"type:uuid" json:"updatedBy"`
+	UpdatedAt  time.Time  `json:"updatedAt"`
+}
+
+// ReplyTemplate is a canned reply, Shared ones are visible to every dashboard user
+type ReplyTemplate struct {
 	ID        uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
 	UserID    uuid.UUID `gorm:"type:uuid;index"`
-	VideoID   string    `gorm:"index"`
-	Content   string
-	Tags      pq.StringArray `gorm:"type:text[]"`
+	Name      string    `gorm:"not null"`
+	Text      string    `gorm:"not null"`
+	Shared    bool      `gorm:"default:false;index"`
 	CreatedAt time.Time
 	UpdatedAt time.Time
 }
+
+const (
+	DeletionPending   = "pending"
+	DeletionRunning   = "running"
+	DeletionDone      = "done"
+	DeletionCancelled = "cancelled"
+	DeletionFailed    = "failed"
+)
+
+// PendingDeletion is a comment deletion waiting out its undo window
+type PendingDeletion struct {
+	ID        uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	CommentID string     `gorm:"index;not null" json:"commentId"`
+	UserID    uuid.UUID  `gorm:"type:uuid;index" json:"userId"`
+	Status    string     `gorm:"index;not null" json:"status"`
+	ExecuteAt time.Time  `gorm:"index" json:"executeAt"`
+	ClaimedAt *time.Time `json:"-"`
+	Error     string     `json:"error,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+const (
+	JobComment = "comment"
+	JobReply   = "reply"
+
+	JobPending   = "pending"
+	JobRunning   = "running"
+	JobDone      = "done"
+	JobCancelled = "cancelled"
+	JobFailed    = "failed"
+)
+
+// CommentJob is a comment or reply to post at ScheduledAt, retried with
+// backoff from NextAttemptAt until MaxJobAttempts. Unconfirmed is set when an
+// attempt may have posted it, the next one checks YouTube before posting.
+type CommentJob struct {
+	ID            uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	UserID        uuid.UUID  `gorm:"type:uuid;index" json:"userId"`
+	Kind          string     `gorm:"not null" json:"kind"`
+	VideoID       string     `json:"videoId,omitempty"`
+	ParentID      string     `json:"parentId,omitempty"`
+	Text          string     `gorm:"not null" json:"text"`
+	ScheduledAt   time.Time  `json:"scheduledAt"`
+	NextAttemptAt time.Time  `gorm:"index" json:"nextAttemptAt"`
+	Status        string     `gorm:"index;not null" json:"status"`
+	Attempts      int        `json:"attempts"`
+	Unconfirmed   bool       `json:"unconfirmed"`
+	ClaimedAt     *time.Time `json:"-"`
+	LastError     string     `json:"lastError,omitempty"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	UpdatedAt     time.Time  `json:"updatedAt"`
+}
+
+// IdempotencyRecord remembers the response to a request sent with an
+// Idempotency-Key header, Status 0 means the first request is still running
+type IdempotencyRecord struct {
+	UserID      uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Key         string    `gorm:"primaryKey"`
+	RequestHash string    `gorm:"not null"`
+	Status      int
+	ContentType string
+	Body        []byte
+	CreatedAt   time.Time `gorm:"index"`
+}
+
+const (
+	RuleHold   = "hold"
+	RuleDelete = "delete"
+	RuleReply  = "reply"
+	RuleTag    = "tag"
+	RuleNotify = "notify"
+)
+
+// CommentRule runs Action on newly synced comments matching every condition
+// that is set, empty conditions match anything
+type CommentRule struct {
+	ID              uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	UserID          uuid.UUID  `gorm:"type:uuid;index" json:"userId"`
+	Name            string     `gorm:"not null" json:"name"`
+	Enabled         bool       `gorm:"not null" json:"enabled"`
+	Position        int        `json:"position"`
+	TextPattern     string     `json:"textPattern,omitempty"`   // regexp
+	AuthorPattern   string     `json:"authorPattern,omitempty"` // regexp on the display name
+	AuthorChannelID string     `json:"authorChannelId,omitempty"`
+	VideoID         string     `json:"videoId,omitempty"`
+	MinLength       int        `json:"minLength,omitempty"`
+	MaxLength       int        `json:"maxLength,omitempty"`
+	Language        string     `json:"language,omitempty"`
+	Action          string     `gorm:"not null" json:"action"`
+	TemplateID      *uuid.UUID `gorm:"type:uuid" json:"templateId,omitempty"`
+	Tag             string     `json:"tag,omitempty"`
+	CreatedAt       time.Time  `json:"createdAt"`
+	UpdatedAt       time.Time  `json:"updatedAt"`
+}
+
+// CommentRuleHit records a rule firing on a comment, it also keeps a rule
+// from running twice on the same comment
+type CommentRuleHit struct {
+	RuleID    uuid.UUID `gorm:"type:uuid;primaryKey" json:"ruleId"`
+	CommentID string    `gorm:"primaryKey" json:"commentId"`
+	UserID    uuid.UUID `gorm:"type:uuid;index" json:"userId"`
+	VideoID   string    `json:"videoId"`
+	Action    string    `gorm:"index" json:"action"`
+	Error     string    `json:"error,omitempty"`
+	CreatedAt time.Time `gorm:"index" json:"createdAt"`
+}
+
+// CommentTag is a label put on a comment by a rule
+type CommentTag struct {
+	CommentID string    `gorm:"primaryKey" json:"commentId"`
+	Tag       string    `gorm:"primaryKey" json:"tag"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+const (
+	AuthorBlock = "block"
+	AuthorAllow = "allow"
+)
+
+// AuthorListEntry puts a commenter on the user's blocklist or allowlist,
+// Action is the moderation applied to new comments of blocked authors
+type AuthorListEntry struct {
+	UserID            uuid.UUID `gorm:"type:uuid;primaryKey" json:"userId"`
+	AuthorChannelID   string    `gorm:"primaryKey" json:"authorChannelId"`
+	List              string    `gorm:"not null;index" json:"list"`
+	Action            string    `json:"action,omitempty"`
+	AuthorDisplayName string    `json:"authorDisplayName,omitempty"`
+	Note              string    `json:"note,omitempty"`
+	CreatedAt         time.Time `json:"createdAt"`
+	UpdatedAt         time.Time `json:"updatedAt"`
+}
+
+// CommenterProfile holds the user's private notes and labels on a commenter
+type CommenterProfile struct {
+	UserID          uuid.UUID      `gorm:"type:uuid;primaryKey" json:"userId"`
+	AuthorChannelID string         `gorm:"primaryKey" json:"authorChannelId"`
+	Note            string         `json:"note"`
+	Labels          pq.StringArray `gorm:"type:text[]" json:"labels"`
+	UpdatedAt       time.Time      `json:"updatedAt"`
+}
+
+// Giveaway commits to a seed when it is created, SeedHash is published up
+// front and Seed only once the winners are drawn. Anyone with the seed and
+// the entry ids can repeat the draw, see routes/giveaway.go
+type Giveaway struct {
+	ID             uuid.UUID      `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	UserID         uuid.UUID      `gorm:"type:uuid;index" json:"userId"`
+	VideoID        string         `gorm:"index" json:"videoId"`
+	Seed           string         `gorm:"not null" json:"seed,omitempty"`
+	SeedHash       string         `json:"seedHash"`
+	Winners        int            `json:"winners"`
+	Keyword        string         `json:"keyword,omitempty"`
+	EndsAt         *time.Time     `json:"endsAt,omitempty"`
+	OnePerAuthor   bool           `json:"onePerAuthor"`
+	ExcludeBlocked bool           `json:"excludeBlocked"`
+	Entries        int            `json:"entries"`
+	EntriesHash    string         `json:"entriesHash,omitempty"`
+	EntryIDs       pq.StringArray `gorm:"type:text[]" json:"entryIds,omitempty"`
+	WinnerIDs      pq.StringArray `gorm:"type:text[]" json:"winnerIds"`
+	DrawnAt        *time.Time     `json:"drawnAt,omitempty"`
+	CreatedAt      time.Time      `json:"createdAt"`
+}
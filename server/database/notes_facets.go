@@ -0,0 +1,90 @@
+package database
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+type NoteFilter struct {
+	VideoIDs []string
+	Tags     []string
+	Month    *time.Time // any time inside the month
+}
+
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+type NoteFacets struct {
+	Videos []FacetCount `json:"videos"`
+	Tags   []FacetCount `json:"tags"`
+	Months []FacetCount `json:"months"`
+}
+
+func (f NoteFilter) scope(userId uuid.UUID) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		db = db.Where("notes.user_id = ?", userId)
+
+		if len(f.VideoIDs) > 0 {
+			db = db.Where("notes.video_id IN ?", f.VideoIDs)
+		}
+		if len(f.Tags) > 0 {
+			db = db.Where("notes.tags @> ?", pq.StringArray(f.Tags))
+		}
+		if f.Month != nil {
+			start := time.Date(f.Month.Year(), f.Month.Month(), 1, 0, 0, 0, 0, time.UTC)
+			db = db.Where("notes.created_at >= ? AND notes.created_at < ?", start, start.AddDate(0, 1, 0))
+		}
+
+		return db
+	}
+}
+
+// GetAllNotes lists a user's own notes across every video
+func GetAllNotes(userId uuid.UUID, filter NoteFilter, limit int, cursor *time.Time) ([]Note, error) {
+	var notes []Note
+
+	query := DB.Preload("Attachments").Scopes(filter.scope(userId)).Order("created_at DESC").Limit(limit)
+	if cursor != nil {
+		query = query.Where("created_at < ?", *cursor)
+	}
+
+	if err := query.Find(&notes).Error; err != nil {
+		return nil, err
+	}
+
+	return notes, openNotes(notes)
+}
+
+// GetNoteFacets counts the notes matching filter by video, tag and month
+func GetNoteFacets(userId uuid.UUID, filter NoteFilter) (NoteFacets, error) {
+	var facets NoteFacets
+
+	err := DB.Model(&Note{}).Scopes(filter.scope(userId)).
+		Select("video_id AS value, COUNT(*) AS count").
+		Group("video_id").Order("count DESC").
+		Scan(&facets.Videos).Error
+	if err != nil {
+		return facets, err
+	}
+
+	err = DB.Table("(?) AS t", DB.Model(&Note{}).Scopes(filter.scope(userId)).Select("unnest(tags) AS tag")).
+		Select("tag AS value, COUNT(*) AS count").
+		Group("tag").Order("count DESC").
+		Scan(&facets.Tags).Error
+	if err != nil {
+		return facets, err
+	}
+
+	err = DB.Model(&Note{}).Scopes(filter.scope(userId)).
+		// the month filter is in UTC, so are the buckets
+		Select("to_char(created_at AT TIME ZONE 'UTC', 'YYYY-MM') AS value, COUNT(*) AS count").
+		Group("value").Order("value DESC").
+		Scan(&facets.Months).Error
+
+	return facets, err
+}
@@ -1,34 +1,153 @@
 package database
 
 import (
-	"github.com/lib/pq"
+	"errors"
 	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+var ErrNoteAccess = errors.New("no access to note")
+
 func InsertNote(note *Note) error {
-	return DB.Create(note).Error
+	plain := note.Content
+	if NotesEncryptionEnabled() {
+		if err := sealContent(note); err != nil {
+			return err
+		}
+	}
+
+	err := DB.Create(note).Error
+	note.Content = plain
+	return err
 }
 
-func GetNotes(videoId string, limit int, cursor *time.Time) ([]Note, error) {
+// visibleTo limits a notes query to notes owned by or shared with userId
+func visibleTo(userId uuid.UUID) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(
+			"notes.user_id = ? OR notes.id IN (?) OR EXISTS (?)",
+			userId,
+			DB.Model(&NoteShare{}).Select("note_id").
+				Where("shared_with_id = ? AND note_id IS NOT NULL", userId),
+			DB.Model(&NoteShare{}).Select("1").
+				Where("shared_with_id = ? AND note_id IS NULL", userId).
+				Where("note_shares.owner_id = notes.user_id AND note_shares.video_id = notes.video_id"),
+		)
+	}
+}
+
+func GetNotes(userId uuid.UUID, videoId string, limit int, cursor *time.Time) ([]Note, error) {
 	var notes []Note
 
-	query := DB.Where("video_id = ?", videoId).Order("created_at DESC").Limit(limit)
+	query := DB.Preload("Attachments").Scopes(visibleTo(userId)).Where("video_id = ?", videoId).Order("created_at DESC").Limit(limit)
 	if cursor != nil {
 		query = query.Where("created_at < ?", *cursor)
 	}
 
-	err := query.Find(&notes).Error
-	return notes, err
+	if err := query.Find(&notes).Error; err != nil {
+		return nil, err
+	}
+
+	return notes, openNotes(notes)
 }
 
-func GetNotesByTags(videoId string, limit int, tags []string, cursor *time.Time) ([]Note, error) {
+func GetNotesByTags(userId uuid.UUID, videoId string, limit int, tags []string, cursor *time.Time) ([]Note, error) {
 	var notes []Note
 
-	query := DB.Where("video_id = ?", videoId).Where("tags @> ?", pq.StringArray(tags)).Order("created_at DESC").Limit(limit)
+	query := DB.Preload("Attachments").Scopes(visibleTo(userId)).Where("video_id = ?", videoId).Where("tags @> ?", pq.StringArray(tags)).Order("created_at DESC").Limit(limit)
 	if cursor != nil {
 		query = query.Where("created_at < ?", *cursor)
 	}
 
-	err := query.Find(&notes).Error
-	return notes, err
+	if err := query.Find(&notes).Error; err != nil {
+		return nil, err
+	}
+
+	return notes, openNotes(notes)
+}
+
+/*
+GetNoteAccess
+- Returns the note and the access level userId holds on it
+- Owners get ShareWrite, a write share beats a read share
+*/
+func GetNoteAccess(noteId string, userId uuid.UUID) (Note, string, error) {
+	var note Note
+	if err := DB.First(&note, "id = ?", noteId).Error; err != nil {
+		return note, "", err
+	}
+
+	if err := openContent(&note); err != nil {
+		return note, "", err
+	}
+
+	if note.UserID == userId {
+		return note, ShareWrite, nil
+	}
+
+	var shares []NoteShare
+	err := DB.Where("shared_with_id = ? AND owner_id = ?", userId, note.UserID).
+		Where("note_id = ? OR (note_id IS NULL AND video_id = ?)", note.ID, note.VideoID).
+		Find(&shares).Error
+	if err != nil {
+		return note, "", err
+	}
+
+	access := ""
+	for _, share := range shares {
+		if share.Access == ShareWrite {
+			return note, ShareWrite, nil
+		}
+		access = share.Access
+	}
+
+	if access == "" {
+		return note, "", ErrNoteAccess
+	}
+	return note, access, nil
+}
+
+func UpdateNote(note *Note, editorId uuid.UUID) error {
+	note.LastEditedBy = editorId
+	note.Encrypted = false
+
+	plain := note.Content
+	if NotesEncryptionEnabled() {
+		if err := sealContent(note); err != nil {
+			return err
+		}
+	}
+
+	err := DB.Model(note).Select("Content", "Tags", "LastEditedBy", "Encrypted", "UpdatedAt").Updates(note).Error
+	note.Content = plain
+	return err
+}
+
+// InsertNoteShare shares a note or video, sharing it again with the same
+// collaborator only changes the access level
+func InsertNoteShare(share *NoteShare) error {
+	conflict := clause.OnConflict{
+		Columns:     []clause.Column{{Name: "note_id"}, {Name: "shared_with_id"}},
+		TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "note_id IS NOT NULL"}}},
+		DoUpdates:   clause.AssignmentColumns([]string{"access"}),
+	}
+	if share.NoteID == nil {
+		conflict.Columns = []clause.Column{{Name: "owner_id"}, {Name: "shared_with_id"}, {Name: "video_id"}}
+		conflict.TargetWhere = clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "note_id IS NULL"}}}
+	}
+	return DB.Clauses(conflict).Create(share).Error
+}
+
+func GetNoteSharesByOwner(ownerId uuid.UUID) ([]NoteShare, error) {
+	var shares []NoteShare
+	err := DB.Where("owner_id = ?", ownerId).Order("created_at DESC").Find(&shares).Error
+	return shares, err
+}
+
+func DeleteNoteShare(id string, ownerId uuid.UUID) error {
+	return DB.Where("id = ? AND owner_id = ?", id, ownerId).Delete(&NoteShare{}).Error
 }
@@ -0,0 +1,38 @@
+package database
+
+import (
+	"github.com/google/uuid"
+)
+
+func InsertReplyTemplate(template *ReplyTemplate) error {
+	return DB.Create(template).Error
+}
+
+// GetReplyTemplates lists the user's own templates and the team's shared ones
+func GetReplyTemplates(userId uuid.UUID) ([]ReplyTemplate, error) {
+	var templates []ReplyTemplate
+	err := DB.Where("user_id = ? OR shared = true", userId).Order("name ASC").Find(&templates).Error
+	return templates, err
+}
+
+// GetReplyTemplate returns a template the user may use
+func GetReplyTemplate(id string, userId uuid.UUID) (ReplyTemplate, error) {
+	var template ReplyTemplate
+	err := DB.First(&template, "id = ? AND (user_id = ? OR shared = true)", id, userId).Error
+	return template, err
+}
+
+// GetOwnReplyTemplate returns a template the user may change
+func GetOwnReplyTemplate(id string, userId uuid.UUID) (ReplyTemplate, error) {
+	var template ReplyTemplate
+	err := DB.First(&template, "id = ? AND user_id = ?", id, userId).Error
+	return template, err
+}
+
+func UpdateReplyTemplate(template *ReplyTemplate) error {
+	return DB.Model(template).Select("Name", "Text", "Shared", "UpdatedAt").Updates(template).Error
+}
+
+func DeleteReplyTemplate(id string, userId uuid.UUID) error {
+	return DB.Where("id = ? AND user_id = ?", id, userId).Delete(&ReplyTemplate{}).Error
+}
@@ -0,0 +1,98 @@
+package database
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm/clause"
+)
+
+func IsRuleAction(action string) bool {
+	switch action {
+	case RuleHold, RuleDelete, RuleReply, RuleTag, RuleNotify:
+		return true
+	}
+	return false
+}
+
+func InsertCommentRule(rule *CommentRule) error {
+	return DB.Create(rule).Error
+}
+
+// GetCommentRules returns the user's rules in evaluation order
+func GetCommentRules(userId uuid.UUID, enabledOnly bool) ([]CommentRule, error) {
+	query := DB.Where("user_id = ?", userId)
+	if enabledOnly {
+		query = query.Where("enabled = true")
+	}
+
+	var rules []CommentRule
+	err := query.Order("position ASC, created_at ASC").Find(&rules).Error
+	return rules, err
+}
+
+func GetCommentRule(id string, userId uuid.UUID) (CommentRule, error) {
+	var rule CommentRule
+	err := DB.First(&rule, "id = ? AND user_id = ?", id, userId).Error
+	return rule, err
+}
+
+func UpdateCommentRule(rule *CommentRule) error {
+	return DB.Model(rule).Select(
+		"Name", "Enabled", "Position",
+		"TextPattern", "AuthorPattern", "AuthorChannelID", "VideoID", "MinLength", "MaxLength", "Language",
+		"Action", "TemplateID", "Tag", "UpdatedAt",
+	).Updates(rule).Error
+}
+
+func DeleteCommentRule(id string, userId uuid.UUID) error {
+	return DB.Where("id = ? AND user_id = ?", id, userId).Delete(&CommentRule{}).Error
+}
+
+// ClaimRuleHit returns false when the rule already fired on the comment
+func ClaimRuleHit(hit *CommentRuleHit) (bool, error) {
+	res := DB.Clauses(clause.OnConflict{DoNothing: true}).Create(hit)
+	return res.RowsAffected == 1, res.Error
+}
+
+func SetRuleHitError(ruleId uuid.UUID, commentId string, err error) error {
+	return DB.Model(&CommentRuleHit{}).
+		Where("rule_id = ? AND comment_id = ?", ruleId, commentId).
+		Update("error", err.Error()).Error
+}
+
+// GetRuleHits lists what the user's rules did, newest first
+func GetRuleHits(userId uuid.UUID, ruleId string, action string, limit int) ([]CommentRuleHit, error) {
+	query := DB.Where("user_id = ?", userId)
+	if ruleId != "" {
+		query = query.Where("rule_id = ?", ruleId)
+	}
+	if action != "" {
+		query = query.Where("action = ?", action)
+	}
+
+	var hits []CommentRuleHit
+	err := query.Order("created_at DESC").Limit(limit).Find(&hits).Error
+	return hits, err
+}
+
+func AddCommentTag(commentId string, tag string) error {
+	return DB.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&CommentTag{CommentID: commentId, Tag: tag}).Error
+}
+
+// GetCommentTags returns the tags of the given comments keyed by comment id
+func GetCommentTags(commentIds []string) (map[string][]string, error) {
+	tags := make(map[string][]string, len(commentIds))
+	if len(commentIds) == 0 {
+		return tags, nil
+	}
+
+	var rows []CommentTag
+	if err := DB.Where("comment_id IN ?", commentIds).Order("tag ASC").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		tags[row.CommentID] = append(tags[row.CommentID], row.Tag)
+	}
+	return tags, nil
+}
@@ -0,0 +1,29 @@
+package database
+
+import (
+	"github.com/google/uuid"
+)
+
+func InsertNoteTemplate(template *NoteTemplate) error {
+	return DB.Create(template).Error
+}
+
+func GetNoteTemplates(userId uuid.UUID) ([]NoteTemplate, error) {
+	var templates []NoteTemplate
+	err := DB.Where("user_id = ?", userId).Order("name ASC").Find(&templates).Error
+	return templates, err
+}
+
+func GetNoteTemplate(id string, userId uuid.UUID) (NoteTemplate, error) {
+	var template NoteTemplate
+	err := DB.First(&template, "id = ? AND user_id = ?", id, userId).Error
+	return template, err
+}
+
+func UpdateNoteTemplate(template *NoteTemplate) error {
+	return DB.Model(template).Select("Name", "Content", "Tags", "UpdatedAt").Updates(template).Error
+}
+
+func DeleteNoteTemplate(id string, userId uuid.UUID) error {
+	return DB.Where("id = ? AND user_id = ?", id, userId).Delete(&NoteTemplate{}).Error
+}
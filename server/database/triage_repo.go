@@ -0,0 +1,48 @@
+package database
+
+import (
+	"slices"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm/clause"
+)
+
+func IsTriageStatus(status string) bool {
+	return slices.Contains([]string{TriageNew, TriageInProgress, TriageAnswered, TriageIgnored}, status)
+}
+
+func UpsertTriage(triage *CommentTriage) error {
+	return DB.Clauses(clause.OnConflict{UpdateAll: true}).Create(triage).Error
+}
+
+// SetTriageStatus changes the status and keeps the current assignee
+func SetTriageStatus(commentId string, status string, userId uuid.UUID) error {
+	triage := CommentTriage{
+		CommentID: commentId,
+		Status:    status,
+		UpdatedBy: userId,
+	}
+
+	return DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "comment_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"status", "updated_by", "updated_at"}),
+	}).Create(&triage).Error
+}
+
+// GetTriages returns the triage rows of the given comments keyed by comment id
+func GetTriages(commentIds []string) (map[string]CommentTriage, error) {
+	triages := make(map[string]CommentTriage, len(commentIds))
+	if len(commentIds) == 0 {
+		return triages, nil
+	}
+
+	var rows []CommentTriage
+	if err := DB.Where("comment_id IN ?", commentIds).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		triages[row.CommentID] = row
+	}
+	return triages, nil
+}
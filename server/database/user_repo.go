@@ -87,3 +87,30 @@ func GetToken(googleUserId string) (string, error) {
 
 	return utils.Decrypt(token.RefreshTokenEnc)
 }
+
+func GetUserByGoogleId(googleUserId string) (User, error) {
+	var user User
+	err := DB.First(&user, "google_user_id = ?", googleUserId).Error
+	return user, err
+}
+
+func GetUserByEmail(email string) (User, error) {
+	var user User
+	err := DB.First(&user, "email = ?", email).Error
+	return user, err
+}
+
+// GetUsersWithTokens lists users that still have a usable refresh token
+func GetUsersWithTokens() ([]User, error) {
+	var users []User
+	err := DB.Joins("JOIN tokens ON tokens.user_id = users.id").
+		Where("tokens.revoked = false").
+		Find(&users).Error
+	return users, err
+}
+
+func GetUserById(id uuid.UUID) (User, error) {
+	var user User
+	err := DB.First(&user, "id = ?", id).Error
+	return user, err
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -9,6 +10,7 @@ import (
 
 	"yt_dashboard.com/database"
 	"yt_dashboard.com/routes"
+	"yt_dashboard.com/storage"
 )
 
 func main() {
@@ -17,16 +19,48 @@ func main() {
 		fmt.Println("No env file found") // no file in prod
 	}
 
-	database.DbInit()
+	if err := database.DbInit(); err != nil {
+		fmt.Println("Database unavailable:", err)
+		os.Exit(1)
+	}
+	// attachment handlers expect a store, don't serve without one
+	if err := storage.StoreInit(); err != nil {
+		fmt.Println("Blob store unavailable:", err)
+		os.Exit(1)
+	}
+
+	if database.NotesEncryptionEnabled() {
+		encryptExistingNotes()
+	}
+
+	routes.StartCommentSync()
+	routes.StartDeletionWorker()
+	routes.StartJobRunner()
 	runServer()
 }
 
+// encrypts notes and attachments stored before NOTES_ENCRYPTION was turned on
+func encryptExistingNotes() {
+	notes, err := database.EncryptExistingNotes()
+	if err != nil {
+		fmt.Println("Note encryption migration failed:", err)
+	}
+
+	attachments, err := database.EncryptExistingAttachments(storage.Store)
+	if err != nil {
+		fmt.Println("Attachment encryption migration failed:", err)
+	}
+
+	fmt.Printf("Encrypted %d notes and %d attachments\n", notes, attachments)
+}
+
 func runServer() {
 	r := gin.Default()
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"http://localhost:5173"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Content-Type", "Authorization"},
+		AllowHeaders:     []string{"Content-Type", "Authorization", "Idempotency-Key"},
+		ExposeHeaders:    []string{"Idempotent-Replayed"},
 		AllowCredentials: true,
 	}))
 
@@ -36,14 +70,62 @@ func runServer() {
 	//r.GET("/logout", routes.Logout)
 	r.GET("/channel", routes.VerifyUser(), routes.GetChannel)
 	r.GET("/comments", routes.VerifyUser(), routes.GetCommentThread)
+	r.GET("/comments/replies", routes.VerifyUser(), routes.GetCommentReplies)
+	r.POST("/comments/sync", routes.VerifyUser(), routes.SyncComments)
+	r.GET("/comments/inbox", routes.VerifyUser(), routes.GetCommentInbox)
+	r.PUT("/comments/triage", routes.VerifyUser(), routes.UpdateTriage)
+	r.GET("/comments/held", routes.VerifyUser(), routes.GetHeldComments)
+	r.POST("/comments/moderate", routes.VerifyUser(), routes.Idempotent(), routes.ModerateComments)
+	r.POST("/comments/spam", routes.VerifyUser(), routes.Idempotent(), routes.MarkCommentsAsSpam)
 	//r.PUT("/video/description", routes.VerifyUser(), routes.UpdateVideoDescription)
 	//r.PUT("/video/title", routes.VerifyUser(), routes.UpdateVideoTitle)
-	r.POST("/comments", routes.VerifyUser(), routes.AddComment)
-	r.POST("/comments/reply", routes.VerifyUser(), routes.ReplyToComment)
-	r.DELETE("/comments", routes.VerifyUser(), routes.DeleteComment)
+	r.POST("/comments", routes.VerifyUser(), routes.Idempotent(), routes.AddComment)
+	r.POST("/comments/reply", routes.VerifyUser(), routes.Idempotent(), routes.ReplyToComment)
+	r.PUT("/comments", routes.VerifyUser(), routes.Idempotent(), routes.UpdateComment)
+	r.POST("/comments/templates", routes.VerifyUser(), routes.CreateReplyTemplate)
+	r.GET("/comments/templates", routes.VerifyUser(), routes.GetReplyTemplates)
+	r.PUT("/comments/templates", routes.VerifyUser(), routes.UpdateReplyTemplate)
+	r.DELETE("/comments/templates", routes.VerifyUser(), routes.DeleteReplyTemplate)
+	r.DELETE("/comments", routes.VerifyUser(), routes.Idempotent(), routes.DeleteComment)
+	r.GET("/comments/scheduled", routes.VerifyUser(), routes.GetScheduledComments)
+	r.DELETE("/comments/scheduled", routes.VerifyUser(), routes.CancelScheduledComment)
+	r.GET("/comments/pending-deletions", routes.VerifyUser(), routes.GetPendingDeletions)
+	r.POST("/comments/undo-delete", routes.VerifyUser(), routes.Idempotent(), routes.UndoDeleteComment)
+	r.POST("/comments/bulk/reply", routes.VerifyUser(), routes.Idempotent(), routes.BulkReply)
+	r.POST("/comments/bulk/delete", routes.VerifyUser(), routes.Idempotent(), routes.BulkDelete)
+	r.POST("/comments/rules", routes.VerifyUser(), routes.CreateCommentRule)
+	r.GET("/comments/rules", routes.VerifyUser(), routes.GetCommentRules)
+	r.PUT("/comments/rules", routes.VerifyUser(), routes.UpdateCommentRule)
+	r.DELETE("/comments/rules", routes.VerifyUser(), routes.DeleteCommentRule)
+	r.POST("/comments/rules/dry-run", routes.VerifyUser(), routes.DryRunCommentRules)
+	r.GET("/comments/rules/hits", routes.VerifyUser(), routes.GetCommentRuleHits)
+	r.POST("/comments/authors", routes.VerifyUser(), routes.AddAuthorToList)
+	r.GET("/comments/authors", routes.VerifyUser(), routes.GetAuthorList)
+	r.DELETE("/comments/authors", routes.VerifyUser(), routes.RemoveAuthorFromList)
+	r.GET("/comments/authors/profile", routes.VerifyUser(), routes.GetCommenterProfile)
+	r.PUT("/comments/authors/profile", routes.VerifyUser(), routes.UpdateCommenterProfile)
+	r.GET("/comments/authors/profiles", routes.VerifyUser(), routes.GetCommenterProfiles)
+	r.GET("/comments/leaderboard", routes.VerifyUser(), routes.GetLeaderboard)
+	r.POST("/comments/giveaway", routes.VerifyUser(), routes.Idempotent(), routes.CreateGiveaway)
+	r.POST("/comments/giveaway/draw", routes.VerifyUser(), routes.Idempotent(), routes.DrawGiveaway)
+	r.GET("/comments/giveaway", routes.VerifyUser(), routes.GetGiveaways)
+	r.GET("/comments/questions", routes.VerifyUser(), routes.GetCommentQuestions)
+	r.GET("/comments/sentiment", routes.VerifyUser(), routes.GetCommentSentiment)
 	r.POST("/ai/title", routes.VerifyUser(), routes.SuggestTitles)
-	r.POST("/notes", routes.VerifyUser(), routes.CreateNote)
+	r.POST("/notes", routes.VerifyUser(), routes.Idempotent(), routes.CreateNote)
 	r.GET("/notes", routes.VerifyUser(), routes.GetNotes)
-	r.DELETE("/notes", routes.VerifyUser(), routes.DeleteNote)
+	r.GET("/notes/all", routes.VerifyUser(), routes.GetAllNotes)
+	r.PUT("/notes", routes.VerifyUser(), routes.Idempotent(), routes.UpdateNote)
+	r.DELETE("/notes", routes.VerifyUser(), routes.Idempotent(), routes.DeleteNote)
+	r.POST("/notes/attachments", routes.VerifyUser(), routes.UploadAttachment)
+	r.GET("/notes/attachments", routes.VerifyUser(), routes.DownloadAttachment)
+	r.DELETE("/notes/attachments", routes.VerifyUser(), routes.DeleteAttachment)
+	r.POST("/notes/templates", routes.VerifyUser(), routes.Idempotent(), routes.CreateNoteTemplate)
+	r.GET("/notes/templates", routes.VerifyUser(), routes.GetNoteTemplates)
+	r.PUT("/notes/templates", routes.VerifyUser(), routes.Idempotent(), routes.UpdateNoteTemplate)
+	r.DELETE("/notes/templates", routes.VerifyUser(), routes.Idempotent(), routes.DeleteNoteTemplate)
+	r.POST("/notes/share", routes.VerifyUser(), routes.Idempotent(), routes.ShareNotes)
+	r.GET("/notes/share", routes.VerifyUser(), routes.GetNoteShares)
+	r.DELETE("/notes/share", routes.VerifyUser(), routes.Idempotent(), routes.RevokeNoteShare)
 	r.Run(":3000")
 }
@@ -5,13 +5,17 @@ import (
 	"encoding/json"
 	"io"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
+
+	"yt_dashboard.com/database"
 )
 
 type AddCommentRequest struct {
-	VideoID string `json:"videoId"`
-	Text    string `json:"text"`
+	VideoID     string     `json:"videoId"`
+	Text        string     `json:"text"`
+	ScheduledAt *time.Time `json:"scheduledAt"`
 }
 
 func AddComment(c *gin.Context) {
@@ -28,12 +32,30 @@ func AddComment(c *gin.Context) {
 		return
 	}
 
+	if body.ScheduledAt != nil && body.ScheduledAt.After(time.Now()) {
+		scheduleCommentJob(c, database.CommentJob{
+			Kind:    database.JobComment,
+			VideoID: body.VideoID,
+			Text:    body.Text,
+		}, *body.ScheduledAt)
+		return
+	}
+
+	if err := postComment(body.VideoID, body.Text, token); err != nil {
+		respondWriteError(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"status": "comment added"})
+}
+
+func postComment(videoId string, text string, token string) error {
 	payload := map[string]any{
 		"snippet": map[string]any{
-			"videoId": body.VideoID,
+			"videoId": videoId,
 			"topLevelComment": map[string]any{
 				"snippet": map[string]any{
-					"textOriginal": body.Text,
+					"textOriginal": text,
 				},
 			},
 		},
@@ -51,16 +73,14 @@ func AddComment(c *gin.Context) {
 
 	res, err := http.DefaultClient.Do(req)
 	if err != nil {
-		c.JSON(500, gin.H{"error": err.Error()})
-		return
+		return err
 	}
 	defer res.Body.Close()
 
 	if res.StatusCode != http.StatusOK {
 		b, _ := io.ReadAll(res.Body)
-		c.JSON(500, gin.H{"error": string(b)})
-		return
+		return &YTError{Status: res.StatusCode, Body: string(b)}
 	}
 
-	c.JSON(200, gin.H{"status": "comment added"})
+	return nil
 }
@@ -0,0 +1,211 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"yt_dashboard.com/database"
+)
+
+type AuthorListRequest struct {
+	AuthorChannelID   string `json:"authorChannelId"`
+	List              string `json:"list"`
+	Action            string `json:"action"`
+	AuthorDisplayName string `json:"authorDisplayName"`
+	Note              string `json:"note"`
+}
+
+func AddAuthorToList(c *gin.Context) {
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "not authenticated"})
+		return
+	}
+	userID := userIDAny.(uuid.UUID)
+
+	var body AuthorListRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	if body.AuthorChannelID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "authorChannelId required"})
+		return
+	}
+	if body.List != database.AuthorBlock && body.List != database.AuthorAllow {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "list must be block or allow"})
+		return
+	}
+
+	// blocked authors are held unless told otherwise, allowed ones need no action
+	action := ""
+	if body.List == database.AuthorBlock {
+		action = body.Action
+		if action == "" {
+			action = "hold"
+		}
+		if _, ok := moderationActions[action]; !ok || action == "publish" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "action must be hold, reject or rejectAndBan"})
+			return
+		}
+	}
+
+	entry := database.AuthorListEntry{
+		UserID:            userID,
+		AuthorChannelID:   body.AuthorChannelID,
+		List:              body.List,
+		Action:            action,
+		AuthorDisplayName: body.AuthorDisplayName,
+		Note:              body.Note,
+	}
+
+	if err := database.UpsertAuthorListEntry(&entry); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// comments synced before the block are moderated now, later ones by the sync
+	if entry.List == database.AuthorBlock {
+		if err := moderateStoredAuthor(entry, c.GetString("accessToken")); err != nil {
+			c.JSON(http.StatusBadGateway, gin.H{"error": "author blocked, but cant moderate their stored comments: " + err.Error()})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, entry)
+}
+
+// moderateStoredAuthor applies a block entry to the author's stored comments
+func moderateStoredAuthor(entry database.AuthorListEntry, token string) error {
+	channelId, err := getMyChannelID(token)
+	if err != nil {
+		return err
+	}
+
+	comments, err := database.GetAuthorComments(channelId, entry.AuthorChannelID, -1) // every stored comment
+	if err != nil {
+		return err
+	}
+
+	lists := map[string]database.AuthorListEntry{entry.AuthorChannelID: entry}
+	_, err = enforceBlocklist(lists, comments, token)
+	return err
+}
+
+func GetAuthorList(c *gin.Context) {
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "not authenticated"})
+		return
+	}
+	userID := userIDAny.(uuid.UUID)
+
+	list := c.Query("list")
+	if list != "" && list != database.AuthorBlock && list != database.AuthorAllow {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "list must be block or allow"})
+		return
+	}
+
+	entries, err := database.GetAuthorList(userID, list)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": entries})
+}
+
+func RemoveAuthorFromList(c *gin.Context) {
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "not authenticated"})
+		return
+	}
+	userID := userIDAny.(uuid.UUID)
+
+	authorChannelId := c.Query("authorChannelId")
+	if authorChannelId == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "authorChannelId required"})
+		return
+	}
+
+	if err := database.DeleteAuthorListEntry(userID, authorChannelId); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "deleted"})
+}
+
+func parseAuthorListFilter(c *gin.Context) (string, error) {
+	filter := c.Query("authorList")
+	if filter != "" && filter != database.AuthorBlock && filter != database.AuthorAllow && filter != "none" {
+		return "", fmt.Errorf("authorList must be block, allow or none")
+	}
+	return filter, nil
+}
+
+/*
+applyAuthorLists
+- Marks threads and replies whose author is on one of the user's lists
+- filter keeps threads whose top level author is on that list, none keeps unlisted ones
+*/
+func applyAuthorLists(items []CommentThreadItem, lists map[string]database.AuthorListEntry, filter string) []CommentThreadItem {
+	filtered := make([]CommentThreadItem, 0, len(items))
+	for _, item := range items {
+		item.AuthorList = lists[item.Snippet.TopLevelComment.Snippet.AuthorChannelId.Value].List
+		for j := range item.Replies.Comments {
+			item.Replies.Comments[j].AuthorList = lists[item.Replies.Comments[j].Snippet.AuthorChannelId.Value].List
+		}
+
+		if filter == "none" && item.AuthorList != "" {
+			continue
+		}
+		if filter != "" && filter != "none" && item.AuthorList != filter {
+			continue
+		}
+		filtered = append(filtered, item)
+	}
+	return filtered
+}
+
+// blockedComments returns the comments by blocked authors, grouped by the
+// moderation action of their entry
+func blockedComments(lists map[string]database.AuthorListEntry, comments []database.Comment) map[string][]string {
+	byAction := map[string][]string{}
+	for _, comment := range comments {
+		entry, ok := lists[comment.AuthorChannelID]
+		if !ok || entry.List != database.AuthorBlock || comment.AuthorChannelID == comment.ChannelID {
+			continue
+		}
+		byAction[entry.Action] = append(byAction[entry.Action], comment.ID)
+	}
+	return byAction
+}
+
+/*
+enforceBlocklist
+- Moderates comments of blocked authors with their entry's action
+- Moderated comments are dropped from the local store, they are no longer public
+- Returns the ids that were moderated
+*/
+func enforceBlocklist(lists map[string]database.AuthorListEntry, comments []database.Comment, token string) (map[string]bool, error) {
+	moderated := map[string]bool{}
+	for action, ids := range blockedComments(lists, comments) {
+		waitForYTWrite()
+		if err := setModerationStatus(ids, moderationActions[action], action == "rejectAndBan", token); err != nil {
+			return moderated, err
+		}
+
+		for _, id := range ids {
+			moderated[id] = true
+			database.DeleteStoredComment(id)
+		}
+	}
+
+	return moderated, nil
+}
@@ -0,0 +1,178 @@
+package routes
+
+import (
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"yt_dashboard.com/database"
+	"yt_dashboard.com/utils"
+)
+
+const (
+	defaultBulkConcurrency = 4
+	defaultYTWriteRate     = 5 // writes per second, shared by every bulk request
+	maxBulkItems           = 200
+)
+
+var (
+	ytWriteLimiter     *utils.RateLimiter
+	ytWriteLimiterOnce sync.Once
+)
+
+type BulkReplyRequest struct {
+	CommentIDs []string          `json:"commentIds"`
+	Text       string            `json:"text"`
+	TemplateID string            `json:"templateId"`
+	Variables  map[string]string `json:"variables"`
+}
+
+type BulkDeleteRequest struct {
+	CommentIDs []string `json:"commentIds"`
+}
+
+type BulkResult struct {
+	CommentID string                    `json:"commentId"`
+	OK        bool                      `json:"ok"`
+	Text      string                    `json:"text,omitempty"`
+	Deletion  *database.PendingDeletion `json:"deletion,omitempty"`
+	Error     string                    `json:"error,omitempty"`
+}
+
+// waitForYTWrite blocks until the next YouTube write is allowed, the limiter
+// is built lazily so YT_WRITE_RATE from the env file is picked up
+func waitForYTWrite() {
+	ytWriteLimiterOnce.Do(func() {
+		ytWriteLimiter = utils.NewRateLimiter(envInt("YT_WRITE_RATE", defaultYTWriteRate))
+	})
+	ytWriteLimiter.Wait()
+}
+
+func envInt(name string, fallback int) int {
+	if v := os.Getenv(name); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			return n
+		}
+	}
+	return fallback
+}
+
+func BulkReply(c *gin.Context) {
+	tokenAny, exists := c.Get("accessToken")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "not authenticated"})
+		return
+	}
+	token := tokenAny.(string)
+	userID := c.MustGet("userID").(uuid.UUID)
+
+	var body BulkReplyRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	if len(body.CommentIDs) == 0 || (body.Text == "" && body.TemplateID == "") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "commentIds and text or templateId required"})
+		return
+	}
+
+	if len(body.CommentIDs) > maxBulkItems {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "too many comments, max " + strconv.Itoa(maxBulkItems)})
+		return
+	}
+
+	var template database.ReplyTemplate
+	if body.TemplateID != "" {
+		var err error
+		template, err = database.GetReplyTemplate(body.TemplateID, userID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "template not found"})
+			return
+		}
+	}
+	videos := newVideoSnippetCache()
+
+	results := runBulk(body.CommentIDs, func(commentId string) (BulkResult, error) {
+		text := body.Text
+		if body.TemplateID != "" {
+			rendered, err := fillReplyTemplate(template, commentId, token, body.Variables, videos)
+			if err != nil {
+				return BulkResult{}, err
+			}
+			text = rendered
+		}
+
+		waitForYTWrite()
+		if err := postReply(commentId, text, token); err != nil {
+			return BulkResult{}, err
+		}
+
+		database.SetTriageStatus(commentId, database.TriageAnswered, userID)
+		return BulkResult{Text: text}, nil
+	})
+
+	c.JSON(http.StatusOK, gin.H{"items": results})
+}
+
+func BulkDelete(c *gin.Context) {
+	tokenAny, exists := c.Get("accessToken")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "not authenticated"})
+		return
+	}
+	token := tokenAny.(string)
+	userID := c.MustGet("userID").(uuid.UUID)
+
+	var body BulkDeleteRequest
+	if err := c.ShouldBindJSON(&body); err != nil || len(body.CommentIDs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "commentIds required"})
+		return
+	}
+
+	if len(body.CommentIDs) > maxBulkItems {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "too many comments, max " + strconv.Itoa(maxBulkItems)})
+		return
+	}
+
+	// queued like single deletions, so a wrong spam wave selection can be undone
+	results := runBulk(body.CommentIDs, func(commentId string) (BulkResult, error) {
+		deletion, err := queueDeletion(commentId, userID, token)
+		return BulkResult{Deletion: deletion}, err
+	})
+
+	c.JSON(http.StatusOK, gin.H{"items": results})
+}
+
+// runBulk calls fn for every id with at most BULK_CONCURRENCY in flight,
+// results keep the order of ids
+func runBulk(ids []string, fn func(id string) (BulkResult, error)) []BulkResult {
+	results := make([]BulkResult, len(ids))
+	sem := make(chan struct{}, envInt("BULK_CONCURRENCY", defaultBulkConcurrency))
+
+	var wg sync.WaitGroup
+	for i, id := range ids {
+		wg.Add(1)
+		sem <- struct{}{}
+
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			result, err := fn(id)
+			result.CommentID = id
+			result.OK = err == nil
+			if err != nil {
+				result.Error = err.Error()
+			}
+			results[i] = result
+		}()
+	}
+
+	wg.Wait()
+	return results
+}
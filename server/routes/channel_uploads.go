@@ -0,0 +1,197 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// uploads change rarely, a new video shows up with its title a bit late
+const uploadsCacheTTL = 10 * time.Minute
+
+// ownsVideo refetches on a miss at most this often, for videos newer than the cache
+const uploadsMissRefetch = time.Minute
+
+type cachedUploads struct {
+	uploads   map[string]VideoSummary
+	fetchedAt time.Time
+}
+
+var (
+	uploadsCache   = map[string]cachedUploads{}
+	uploadsCacheMu sync.RWMutex
+)
+
+// VideoSummary is the cheap per-video info available from the uploads
+// playlist, no videos.list call per item like getVideosList does
+type VideoSummary struct {
+	ID          string `json:"videoId"`
+	Title       string `json:"title"`
+	Thumbnail   string `json:"thumbnail"`
+	PublishedAt string `json:"publishedAt"`
+}
+
+func getUploadsPlaylistID(token string) (string, error) {
+	_, playlistID, err := getMyChannel(token)
+	return playlistID, err
+}
+
+func getMyChannelID(token string) (string, error) {
+	channelID, _, err := getMyChannel(token)
+	return channelID, err
+}
+
+// getMyChannel returns the channel id and uploads playlist id of the token owner
+func getMyChannel(token string) (string, string, error) {
+	reqURL, _ := url.Parse("https://www.googleapis.com/youtube/v3/channels")
+
+	q := reqURL.Query()
+	q.Set("part", "id,contentDetails")
+	q.Set("mine", "true")
+	reqURL.RawQuery = q.Encode()
+
+	req, _ := http.NewRequest(http.MethodGet, reqURL.String(), nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", "", err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(res.Body)
+		return "", "", fmt.Errorf("channels error: %s", body)
+	}
+
+	var channelRes struct {
+		Items []struct {
+			Id             string `json:"id"`
+			ContentDetails struct {
+				RelatedPlaylists struct {
+					Uploads string `json:"uploads"`
+				} `json:"relatedPlaylists"`
+			} `json:"contentDetails"`
+		} `json:"items"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&channelRes); err != nil {
+		return "", "", err
+	}
+
+	if len(channelRes.Items) == 0 {
+		return "", "", fmt.Errorf("no channel found")
+	}
+
+	channel := channelRes.Items[0]
+	return channel.Id, channel.ContentDetails.RelatedPlaylists.Uploads, nil
+}
+
+// getChannelUploads pages through the whole uploads playlist, keyed by video id
+func getChannelUploads(token string) (map[string]VideoSummary, error) {
+	playlistID, err := getUploadsPlaylistID(token)
+	if err != nil {
+		return nil, err
+	}
+
+	uploads := make(map[string]VideoSummary)
+	pageToken := ""
+
+	for {
+		reqURL, _ := url.Parse("https://www.googleapis.com/youtube/v3/playlistItems")
+
+		q := reqURL.Query()
+		q.Set("part", "snippet")
+		q.Set("playlistId", playlistID)
+		q.Set("maxResults", "50")
+		if pageToken != "" {
+			q.Set("pageToken", pageToken)
+		}
+		reqURL.RawQuery = q.Encode()
+
+		req, _ := http.NewRequest(http.MethodGet, reqURL.String(), nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+
+		if res.StatusCode != http.StatusOK {
+			body, _ := io.ReadAll(res.Body)
+			res.Body.Close()
+			return nil, fmt.Errorf("youtube error: %s", body)
+		}
+
+		var ytRes YoutubePlaylistItemsResponse
+		err = json.NewDecoder(res.Body).Decode(&ytRes)
+		res.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range ytRes.Items {
+			videoId := item.Snippet.ResourceID.VideoID
+			uploads[videoId] = VideoSummary{
+				ID:          videoId,
+				Title:       item.Snippet.Title,
+				Thumbnail:   getThumbnail(item.Snippet.Thumbnails),
+				PublishedAt: item.Snippet.PublishedAt,
+			}
+		}
+
+		if ytRes.NextPageToken == "" {
+			return uploads, nil
+		}
+		pageToken = ytRes.NextPageToken
+	}
+}
+
+// getCachedChannelUploads is getChannelUploads behind a per user cache, for
+// handlers that only decorate their results with titles and thumbnails
+func getCachedChannelUploads(userId string, token string) (map[string]VideoSummary, error) {
+	uploadsCacheMu.RLock()
+	cached, ok := uploadsCache[userId]
+	uploadsCacheMu.RUnlock()
+	if ok && time.Since(cached.fetchedAt) < uploadsCacheTTL {
+		return cached.uploads, nil
+	}
+
+	uploads, err := getChannelUploads(token)
+	if err != nil {
+		return nil, err
+	}
+
+	uploadsCacheMu.Lock()
+	uploadsCache[userId] = cachedUploads{uploads: uploads, fetchedAt: time.Now()}
+	uploadsCacheMu.Unlock()
+
+	return uploads, nil
+}
+
+// ownsVideo reports whether videoId is one of the token owner's uploads
+func ownsVideo(userId string, videoId string, token string) (bool, error) {
+	uploads, err := getCachedChannelUploads(userId, token)
+	if err != nil {
+		return false, err
+	}
+	if _, ok := uploads[videoId]; ok {
+		return true, nil
+	}
+
+	uploadsCacheMu.Lock()
+	if time.Since(uploadsCache[userId].fetchedAt) > uploadsMissRefetch {
+		delete(uploadsCache, userId)
+	}
+	uploadsCacheMu.Unlock()
+
+	uploads, err = getCachedChannelUploads(userId, token)
+	if err != nil {
+		return false, err
+	}
+	_, ok := uploads[videoId]
+	return ok, nil
+}
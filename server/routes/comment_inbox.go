@@ -0,0 +1,210 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// YouTube pages scanned per inbox request, most threads are usually answered
+const maxInboxPages = 5
+
+type InboxItem struct {
+	CommentThreadItem
+	VideoTitle string `json:"videoTitle"`
+}
+
+// GetCommentInbox lists top level comments across the channel the owner hasn't replied to
+func GetCommentInbox(c *gin.Context) {
+	tokenAny, exists := c.Get("accessToken")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "not authenticated"})
+		return
+	}
+	token := tokenAny.(string)
+
+	sortBy := c.DefaultQuery("sort", "recent")
+	if sortBy != "recent" && sortBy != "likes" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sort must be recent or likes"})
+		return
+	}
+
+	limit := 20
+	if l := c.Query("limit"); l != "" {
+		fmt.Sscan(l, &limit)
+	}
+	limit = max(limit, 1)
+
+	filter, err := parseTriageFilter(c, c.MustGet("userID").(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	channelId, err := getMyChannelID(token)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	items, nextPageToken, err := collectUnanswered(channelId, c.Query("pageToken"), token, limit, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	uploads, _ := getCachedChannelUploads(c.MustGet("userID").(uuid.UUID).String(), token)
+	inbox := make([]InboxItem, 0, len(items))
+	for _, item := range items {
+		inbox = append(inbox, InboxItem{
+			CommentThreadItem: item,
+			VideoTitle:        uploads[item.Snippet.VideoId].Title,
+		})
+	}
+
+	// YouTube returns newest first, likes can only be ordered within the page
+	if sortBy == "likes" {
+		sort.SliceStable(inbox, func(i, j int) bool {
+			return inbox[i].Snippet.TopLevelComment.Snippet.LikeCount > inbox[j].Snippet.TopLevelComment.Snippet.LikeCount
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"channelId":     channelId,
+		"items":         inbox,
+		"nextPageToken": nextPageToken,
+		// pages stay newest first among themselves, only the items are sorted
+		"sortScope": "page",
+	})
+}
+
+/*
+collectUnanswered
+- Walks channel wide threads newest first until limit unanswered ones are found
+- Triage is filtered first since it only needs the database, replies are fetched one thread at a time
+- Stops after maxInboxPages so one request can't drain the quota
+- The returned token resumes right after the last thread looked at, see inboxPageToken
+*/
+func collectUnanswered(channelId string, pageToken string, token string, limit int, filter triageFilter) ([]CommentThreadItem, string, error) {
+	items := []CommentThreadItem{}
+	after, ytPageToken := parseInboxPageToken(pageToken)
+	nextPageToken := ""
+
+scan:
+	for page := 0; page < maxInboxPages; page++ {
+		res, err := fetchChannelComments(channelId, ytPageToken, token, CommentListOptions{Order: "time", MaxResults: 100})
+		if err != nil {
+			return nil, "", err
+		}
+
+		kept, err := applyTriage(slices.Clone(res.Items), filter)
+		if err != nil {
+			return nil, "", err
+		}
+		candidates := make(map[string]CommentThreadItem, len(kept))
+		for _, item := range kept {
+			candidates[item.Id] = item
+		}
+
+		start := 0
+		if after != "" {
+			// new comments may have shifted the page, without the thread it is rescanned whole
+			if i := slices.IndexFunc(res.Items, func(item CommentThreadItem) bool { return item.Id == after }); i >= 0 {
+				start = i + 1
+			}
+			after = ""
+		}
+
+		for i := start; i < len(res.Items); i++ {
+			item, ok := candidates[res.Items[i].Id]
+			if !ok {
+				continue
+			}
+
+			answered, err := isAnswered(item, channelId, token)
+			if err != nil {
+				return nil, "", err
+			}
+			if !answered {
+				items = append(items, item)
+			}
+
+			if len(items) == limit {
+				nextPageToken = res.NextPageToken
+				if i+1 < len(res.Items) {
+					nextPageToken = inboxPageToken(item.Id, ytPageToken)
+				}
+				break scan
+			}
+		}
+
+		ytPageToken = res.NextPageToken
+		nextPageToken = ytPageToken
+		if ytPageToken == "" {
+			break
+		}
+	}
+
+	markTruncatedReplies(items)
+	if err := markPendingDeletions(items); err != nil {
+		return nil, "", err
+	}
+
+	return items, nextPageToken, nil
+}
+
+// inboxPageToken resumes the scan on a YouTube page after the thread with id
+// after, YouTube tokens can only resume at page boundaries
+func inboxPageToken(after string, ytPageToken string) string {
+	return after + ":" + ytPageToken
+}
+
+// parseInboxPageToken also accepts a plain YouTube page token
+func parseInboxPageToken(pageToken string) (string, string) {
+	after, ytPageToken, ok := strings.Cut(pageToken, ":")
+	if !ok {
+		return "", pageToken
+	}
+	return after, ytPageToken
+}
+
+/*
+isAnswered
+- Reports whether the channel wrote the comment or replied to it
+- The replies embedded in the thread are checked first, the rest are only fetched when needed
+*/
+func isAnswered(item CommentThreadItem, channelId string, token string) (bool, error) {
+	if item.Snippet.TopLevelComment.Snippet.AuthorChannelId.Value == channelId {
+		return true, nil
+	}
+	if item.Snippet.TotalReplyCount == 0 {
+		return false, nil
+	}
+
+	if repliedBy(item.Replies.Comments, channelId) {
+		return true, nil
+	}
+	if len(item.Replies.Comments) >= item.Snippet.TotalReplyCount {
+		return false, nil
+	}
+
+	all, err := fetchAllReplies(item.Id, token)
+	if err != nil {
+		return false, err
+	}
+	return repliedBy(all, channelId), nil
+}
+
+func repliedBy(replies []ReplyComment, channelId string) bool {
+	for _, reply := range replies {
+		if reply.Snippet.AuthorChannelId.Value == channelId {
+			return true
+		}
+	}
+	return false
+}
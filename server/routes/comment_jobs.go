@@ -0,0 +1,205 @@
+package routes
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"yt_dashboard.com/database"
+)
+
+const (
+	jobRunnerInterval = 15 * time.Second
+	// running jobs older than this were claimed by a runner that died
+	jobLease = 5 * time.Minute
+)
+
+// scheduleCommentJob stores job for later and writes the response
+func scheduleCommentJob(c *gin.Context, job database.CommentJob, at time.Time) {
+	job.UserID = c.MustGet("userID").(uuid.UUID)
+	job.Status = database.JobPending
+	job.ScheduledAt = at
+	job.NextAttemptAt = at
+
+	if job.Text == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "text required"})
+		return
+	}
+
+	if err := database.InsertCommentJob(&job); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "scheduled", "job": job})
+}
+
+func GetScheduledComments(c *gin.Context) {
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "not authenticated"})
+		return
+	}
+	userID := userIDAny.(uuid.UUID)
+
+	jobs, err := database.GetCommentJobs(userID, c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": jobs})
+}
+
+func CancelScheduledComment(c *gin.Context) {
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "not authenticated"})
+		return
+	}
+	userID := userIDAny.(uuid.UUID)
+
+	id := c.Query("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id required"})
+		return
+	}
+
+	err := database.CancelCommentJob(id, userID)
+	if errors.Is(err, database.ErrJobNotPending) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "cancelled"})
+}
+
+// StartJobRunner posts scheduled comments and replies once they are due
+func StartJobRunner() {
+	go func() {
+		ticker := time.NewTicker(jobRunnerInterval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			runDueJobs()
+		}
+	}()
+}
+
+func runDueJobs() {
+	due, err := database.ClaimDueJobs(time.Now(), jobLease)
+	if err != nil {
+		fmt.Println("job runner:", err)
+	}
+
+	tokens := map[uuid.UUID]string{}
+
+	for _, job := range due {
+		token, ok := tokens[job.UserID]
+		if !ok {
+			user, err := database.GetUserById(job.UserID)
+			if err == nil {
+				token, err = accessTokenFor(user.GoogleUserID)
+			}
+			if err != nil {
+				database.FinishCommentJob(job, err, true)
+				continue
+			}
+			tokens[job.UserID] = token
+		}
+
+		err := runCommentJob(&job, token)
+		retry := true
+		if err != nil {
+			switch classifyWriteError(err) {
+			case writeRejected:
+				retry = false
+			case writeUnknown:
+				job.Unconfirmed = true
+			}
+		}
+		database.FinishCommentJob(job, err, retry)
+	}
+}
+
+// runCommentJob clears job.Unconfirmed once YouTube shows the comment isn't there
+func runCommentJob(job *database.CommentJob, token string) error {
+	if job.Unconfirmed {
+		posted, err := alreadyPosted(*job, token)
+		if err != nil {
+			return err
+		}
+		if posted {
+			if job.Kind == database.JobReply {
+				database.SetTriageStatus(job.ParentID, database.TriageAnswered, job.UserID)
+			}
+			return nil
+		}
+		job.Unconfirmed = false
+	}
+
+	switch job.Kind {
+	case database.JobComment:
+		waitForYTWrite()
+		return postComment(job.VideoID, job.Text, token)
+	case database.JobReply:
+		waitForYTWrite()
+		if err := postReply(job.ParentID, job.Text, token); err != nil {
+			return err
+		}
+		// the reply is out, a triage failure must not trigger a retry
+		database.SetTriageStatus(job.ParentID, database.TriageAnswered, job.UserID)
+		return nil
+	default:
+		return fmt.Errorf("unknown job kind %q", job.Kind)
+	}
+}
+
+/*
+alreadyPosted
+- Looks for the job's text posted by the channel, after an attempt that may have gone through
+- Replies are checked against every reply of the parent, comments against the newest page of the video
+*/
+func alreadyPosted(job database.CommentJob, token string) (bool, error) {
+	channelId, err := getMyChannelID(token)
+	if err != nil {
+		return false, err
+	}
+
+	snippets := []CommentSnippet{}
+	switch job.Kind {
+	case database.JobReply:
+		replies, err := fetchAllReplies(job.ParentID, token)
+		if err != nil {
+			return false, err
+		}
+		for _, reply := range replies {
+			snippets = append(snippets, reply.Snippet)
+		}
+	case database.JobComment:
+		page, err := fetchComments(job.VideoID, "", token, CommentListOptions{Order: "time", MaxResults: 100})
+		if err != nil {
+			return false, err
+		}
+		for _, item := range page.Items {
+			snippets = append(snippets, item.Snippet.TopLevelComment.Snippet)
+		}
+	}
+
+	text := strings.TrimSpace(job.Text)
+	for _, s := range snippets {
+		if s.AuthorChannelId.Value == channelId && strings.TrimSpace(s.TextOriginal) == text {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,174 @@
+package routes
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ids per setModerationStatus / markAsSpam call
+const moderationBatchSize = 50
+
+type ModerateRequest struct {
+	CommentIDs []string `json:"commentIds"`
+	Action     string   `json:"action"`
+}
+
+type SpamRequest struct {
+	CommentIDs []string `json:"commentIds"`
+}
+
+// dashboard actions to YouTube moderation statuses
+var moderationActions = map[string]string{
+	"hold":         "heldForReview",
+	"publish":      "published",
+	"reject":       "rejected",
+	"rejectAndBan": "rejected",
+}
+
+func ModerateComments(c *gin.Context) {
+	tokenAny, exists := c.Get("accessToken")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "not authenticated"})
+		return
+	}
+	token := tokenAny.(string)
+
+	var body ModerateRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	status, ok := moderationActions[body.Action]
+	if !ok || len(body.CommentIDs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "commentIds and action (hold, publish, reject, rejectAndBan) required"})
+		return
+	}
+
+	if err := setModerationStatus(body.CommentIDs, status, body.Action == "rejectAndBan", token); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":     "moderated",
+		"commentIds": body.CommentIDs,
+	})
+}
+
+func MarkCommentsAsSpam(c *gin.Context) {
+	tokenAny, exists := c.Get("accessToken")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "not authenticated"})
+		return
+	}
+	token := tokenAny.(string)
+
+	var body SpamRequest
+	if err := c.ShouldBindJSON(&body); err != nil || len(body.CommentIDs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "commentIds required"})
+		return
+	}
+
+	if err := markAsSpam(body.CommentIDs, token); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":     "marked as spam",
+		"commentIds": body.CommentIDs,
+	})
+}
+
+// GetHeldComments lists comments held for review on a video, or the whole channel without videoId
+func GetHeldComments(c *gin.Context) {
+	tokenAny, exists := c.Get("accessToken")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "not authenticated"})
+		return
+	}
+	token := tokenAny.(string)
+
+	opts := CommentListOptions{ModerationStatus: "heldForReview", MaxResults: 100}
+	pageToken := c.Query("pageToken")
+
+	var (
+		res *YTCommentThreadResponse
+		err error
+	)
+	if videoId := c.Query("videoId"); videoId != "" {
+		res, err = fetchComments(videoId, pageToken, token, opts)
+	} else {
+		channelId, chErr := getMyChannelID(token)
+		if chErr != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": chErr.Error()})
+			return
+		}
+		res, err = fetchChannelComments(channelId, pageToken, token, opts)
+	}
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func setModerationStatus(commentIds []string, status string, banAuthor bool, token string) error {
+	for start := 0; start < len(commentIds); start += moderationBatchSize {
+		end := min(start+moderationBatchSize, len(commentIds))
+
+		q := url.Values{}
+		q.Set("id", strings.Join(commentIds[start:end], ","))
+		q.Set("moderationStatus", status)
+		if banAuthor {
+			q.Set("banAuthor", "true")
+		}
+
+		if err := postModeration("setModerationStatus", q, token); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func markAsSpam(commentIds []string, token string) error {
+	for start := 0; start < len(commentIds); start += moderationBatchSize {
+		end := min(start+moderationBatchSize, len(commentIds))
+
+		q := url.Values{}
+		q.Set("id", strings.Join(commentIds[start:end], ","))
+
+		if err := postModeration("markAsSpam", q, token); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func postModeration(method string, q url.Values, token string) error {
+	reqURL := "https://www.googleapis.com/youtube/v3/comments/" + method + "?" + q.Encode()
+
+	req, _ := http.NewRequest(http.MethodPost, reqURL, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusNoContent {
+		b, _ := io.ReadAll(res.Body)
+		return fmt.Errorf("%s error: %s", method, b)
+	}
+
+	return nil
+}
@@ -0,0 +1,181 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"yt_dashboard.com/database"
+	"yt_dashboard.com/utils"
+)
+
+const (
+	maxQuestionComments       = 5000
+	defaultQuestionDays       = 90
+	defaultQuestionClusters   = 20
+	defaultQuestionMinSize    = 2
+	defaultQuestionSimilarity = 0.3
+	questionExamples          = 3
+)
+
+type QuestionExample struct {
+	CommentID         string    `json:"commentId"`
+	VideoID           string    `json:"videoId"`
+	AuthorDisplayName string    `json:"authorDisplayName"`
+	TextOriginal      string    `json:"textOriginal"`
+	LikeCount         int       `json:"likeCount"`
+	PublishedAt       time.Time `json:"publishedAt"`
+}
+
+type QuestionCluster struct {
+	Count          int               `json:"count"`
+	Videos         int               `json:"videos"`
+	Likes          int               `json:"likes"`
+	Terms          []string          `json:"terms"`
+	Representative QuestionExample   `json:"representative"`
+	Examples       []QuestionExample `json:"examples"`
+}
+
+/*
+GetCommentQuestions
+- Picks the questions out of synced comments, per video or channel-wide
+- Groups similar ones by TF-IDF cosine similarity, all computed locally
+- Each cluster has its closest-to-center question and the most liked examples
+*/
+func GetCommentQuestions(c *gin.Context) {
+	tokenAny, exists := c.Get("accessToken")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "not authenticated"})
+		return
+	}
+	token := tokenAny.(string)
+
+	days, err := queryInt(c, "days", defaultQuestionDays, 0, 3650)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	limit, err := queryInt(c, "limit", defaultQuestionClusters, 1, 100)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	minSize, err := queryInt(c, "minSize", defaultQuestionMinSize, 1, 1000)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	similarity := defaultQuestionSimilarity
+	if s := c.Query("similarity"); s != "" {
+		similarity, err = strconv.ParseFloat(s, 64)
+		if err != nil || similarity <= 0 || similarity > 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "similarity must be above 0 and at most 1"})
+			return
+		}
+	}
+
+	channelId, err := getMyChannelID(token)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	since := time.Time{}
+	if days > 0 {
+		since = time.Now().AddDate(0, 0, -days)
+	}
+
+	comments, err := database.GetRecentComments(channelId, c.Query("videoId"), since, maxQuestionComments)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	questions := []database.Comment{}
+	docs := [][]string{}
+	for _, comment := range comments {
+		if comment.AuthorChannelID == channelId {
+			continue
+		}
+		if !utils.IsQuestion(comment.TextOriginal) {
+			continue
+		}
+		questions = append(questions, comment)
+		docs = append(docs, utils.Tokenize(comment.TextOriginal))
+	}
+
+	clusters := []QuestionCluster{}
+	for _, tc := range utils.ClusterTexts(docs, similarity) {
+		if len(tc.Members) < minSize {
+			// largest first, the rest are smaller still
+			break
+		}
+
+		members := make([]database.Comment, len(tc.Members))
+		videos := map[string]bool{}
+		likes := 0
+		for i, m := range tc.Members {
+			members[i] = questions[m]
+			videos[questions[m].VideoID] = true
+			likes += questions[m].LikeCount
+		}
+
+		sort.SliceStable(members, func(i, j int) bool {
+			return members[i].LikeCount > members[j].LikeCount
+		})
+
+		examples := []QuestionExample{}
+		for _, m := range members[:min(questionExamples, len(members))] {
+			examples = append(examples, toQuestionExample(m))
+		}
+
+		clusters = append(clusters, QuestionCluster{
+			Count:          len(tc.Members),
+			Videos:         len(videos),
+			Likes:          likes,
+			Terms:          tc.Terms,
+			Representative: toQuestionExample(questions[tc.Representative]),
+			Examples:       examples,
+		})
+
+		if len(clusters) == limit {
+			break
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"scanned":   len(comments),
+		"questions": len(questions),
+		"items":     clusters,
+	})
+}
+
+func toQuestionExample(comment database.Comment) QuestionExample {
+	return QuestionExample{
+		CommentID:         comment.ID,
+		VideoID:           comment.VideoID,
+		AuthorDisplayName: comment.AuthorDisplayName,
+		TextOriginal:      comment.TextOriginal,
+		LikeCount:         comment.LikeCount,
+		PublishedAt:       comment.PublishedAt,
+	}
+}
+
+// queryInt reads an optional integer query parameter within [lo, hi]
+func queryInt(c *gin.Context, name string, fallback int, lo int, hi int) (int, error) {
+	v := c.Query(name)
+	if v == "" {
+		return fallback, nil
+	}
+
+	n, err := strconv.Atoi(v)
+	if err != nil || n < lo || n > hi {
+		return 0, fmt.Errorf("%s must be between %d and %d", name, lo, hi)
+	}
+	return n, nil
+}
@@ -0,0 +1,100 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+)
+
+type YTCommentListResponse struct {
+	NextPageToken string         `json:"nextPageToken"`
+	Items         []ReplyComment `json:"items"`
+}
+
+// GetCommentReplies returns every reply of a thread, not just the inline ones
+func GetCommentReplies(c *gin.Context) {
+	tokenAny, exists := c.Get("accessToken")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "not authenticated"})
+		return
+	}
+	token := tokenAny.(string)
+
+	parentId := c.Query("parentId")
+	if parentId == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "parentId required"})
+		return
+	}
+
+	replies, err := fetchAllReplies(parentId, token)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"parentId": parentId,
+		"total":    len(replies),
+		"items":    replies,
+	})
+}
+
+func fetchAllReplies(parentId string, token string) ([]ReplyComment, error) {
+	replies := []ReplyComment{}
+	pageToken := ""
+
+	for {
+		page, err := fetchReplies(parentId, pageToken, token)
+		if err != nil {
+			return nil, err
+		}
+
+		replies = append(replies, page.Items...)
+
+		if page.NextPageToken == "" {
+			return replies, nil
+		}
+		pageToken = page.NextPageToken
+	}
+}
+
+func fetchReplies(parentId string, pageToken string, token string) (*YTCommentListResponse, error) {
+	reqURL, _ := url.Parse("https://www.googleapis.com/youtube/v3/comments")
+
+	q := reqURL.Query()
+	q.Set("part", "snippet")
+	q.Set("parentId", parentId)
+	q.Set("maxResults", "100")
+	q.Set("textFormat", "plainText")
+
+	if pageToken != "" {
+		q.Set("pageToken", pageToken)
+	}
+
+	reqURL.RawQuery = q.Encode()
+
+	req, _ := http.NewRequest(http.MethodGet, reqURL.String(), nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(res.Body)
+		return nil, fmt.Errorf("comments error: %s", body)
+	}
+
+	var ytRes YTCommentListResponse
+	if err := json.NewDecoder(res.Body).Decode(&ytRes); err != nil {
+		return nil, err
+	}
+
+	return &ytRes, nil
+}
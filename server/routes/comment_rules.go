@@ -0,0 +1,547 @@
+package routes
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"yt_dashboard.com/database"
+	"yt_dashboard.com/utils"
+)
+
+const (
+	defaultDryRunLimit = 500
+	maxDryRunLimit     = 5000
+	defaultRuleHits    = 100
+)
+
+type CommentRuleRequest struct {
+	Name            string `json:"name"`
+	Enabled         *bool  `json:"enabled"`
+	Position        int    `json:"position"`
+	TextPattern     string `json:"textPattern"`
+	AuthorPattern   string `json:"authorPattern"`
+	AuthorChannelID string `json:"authorChannelId"`
+	VideoID         string `json:"videoId"`
+	MinLength       int    `json:"minLength"`
+	MaxLength       int    `json:"maxLength"`
+	Language        string `json:"language"`
+	Action          string `json:"action"`
+	TemplateID      string `json:"templateId"`
+	Tag             string `json:"tag"`
+}
+
+type DryRunRequest struct {
+	RuleID  string              `json:"ruleId"`
+	Rule    *CommentRuleRequest `json:"rule"` // an unsaved rule to try out
+	VideoID string              `json:"videoId"`
+	Limit   int                 `json:"limit"`
+}
+
+type DryRunMatch struct {
+	CommentID         string    `json:"commentId"`
+	VideoID           string    `json:"videoId"`
+	AuthorDisplayName string    `json:"authorDisplayName"`
+	TextOriginal      string    `json:"textOriginal"`
+	PublishedAt       time.Time `json:"publishedAt"`
+}
+
+type DryRunResult struct {
+	Rule    database.CommentRule `json:"rule"`
+	Count   int                  `json:"count"`
+	Matches []DryRunMatch        `json:"matches"`
+}
+
+// compiledRule is a rule with its patterns parsed once per run
+type compiledRule struct {
+	database.CommentRule
+	text   *regexp.Regexp
+	author *regexp.Regexp
+}
+
+func CreateCommentRule(c *gin.Context) {
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "not authenticated"})
+		return
+	}
+	userID := userIDAny.(uuid.UUID)
+
+	var body CommentRuleRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	rule := database.CommentRule{UserID: userID}
+	if err := body.apply(&rule, userID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := database.InsertCommentRule(&rule); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+func GetCommentRules(c *gin.Context) {
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "not authenticated"})
+		return
+	}
+	userID := userIDAny.(uuid.UUID)
+
+	rules, err := database.GetCommentRules(userID, false)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": rules})
+}
+
+func UpdateCommentRule(c *gin.Context) {
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "not authenticated"})
+		return
+	}
+	userID := userIDAny.(uuid.UUID)
+
+	id := c.Query("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id required"})
+		return
+	}
+
+	var body CommentRuleRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	rule, err := database.GetCommentRule(id, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "rule not found"})
+		return
+	}
+
+	if err := body.apply(&rule, userID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := database.UpdateCommentRule(&rule); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+func DeleteCommentRule(c *gin.Context) {
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "not authenticated"})
+		return
+	}
+	userID := userIDAny.(uuid.UUID)
+
+	id := c.Query("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id required"})
+		return
+	}
+
+	if err := database.DeleteCommentRule(id, userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "deleted"})
+}
+
+// GetCommentRuleHits is the rules' activity log, action=notify doubles as
+// the notification feed
+func GetCommentRuleHits(c *gin.Context) {
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "not authenticated"})
+		return
+	}
+	userID := userIDAny.(uuid.UUID)
+
+	limit := defaultRuleHits
+	if l := c.Query("limit"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil || n < 1 || n > 1000 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 1000"})
+			return
+		}
+		limit = n
+	}
+
+	hits, err := database.GetRuleHits(userID, c.Query("ruleId"), c.Query("action"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": hits})
+}
+
+/*
+DryRunCommentRules
+- Evaluates rules over stored comments of the channel without acting on YouTube
+- Runs the given unsaved rule, the saved rule ruleId, or every enabled rule
+- Unlike live runs, comments older than the rule are included
+*/
+func DryRunCommentRules(c *gin.Context) {
+	tokenAny, exists := c.Get("accessToken")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "not authenticated"})
+		return
+	}
+	token := tokenAny.(string)
+	userID := c.MustGet("userID").(uuid.UUID)
+
+	var body DryRunRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	if body.Limit == 0 {
+		body.Limit = defaultDryRunLimit
+	}
+	if body.Limit < 1 || body.Limit > maxDryRunLimit {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("limit must be between 1 and %d", maxDryRunLimit)})
+		return
+	}
+
+	var rules []database.CommentRule
+	switch {
+	case body.Rule != nil:
+		rule := database.CommentRule{UserID: userID}
+		if err := body.Rule.apply(&rule, userID); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		rules = append(rules, rule)
+	case body.RuleID != "":
+		rule, err := database.GetCommentRule(body.RuleID, userID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "rule not found"})
+			return
+		}
+		rules = append(rules, rule)
+	default:
+		var err error
+		rules, err = database.GetCommentRules(userID, true)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	compiled, err := compileRules(rules)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	channelId, err := getMyChannelID(token)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	comments, err := database.GetRecentComments(channelId, body.VideoID, time.Time{}, body.Limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	lists, err := database.GetAuthorLists(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	results := make([]DryRunResult, len(compiled))
+	for i, rule := range compiled {
+		results[i] = DryRunResult{Rule: rule.CommentRule, Matches: []DryRunMatch{}}
+	}
+
+	for _, comment := range comments {
+		// live runs never see allowlisted authors
+		if lists[comment.AuthorChannelID].List == database.AuthorAllow {
+			continue
+		}
+
+		for _, i := range matchingRules(compiled, comment, false) {
+			results[i].Count++
+			results[i].Matches = append(results[i].Matches, DryRunMatch{
+				CommentID:         comment.ID,
+				VideoID:           comment.VideoID,
+				AuthorDisplayName: comment.AuthorDisplayName,
+				TextOriginal:      comment.TextOriginal,
+				PublishedAt:       comment.PublishedAt,
+			})
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"scanned": len(comments),
+		"items":   results,
+	})
+}
+
+// apply validates the request and copies it onto rule
+func (r CommentRuleRequest) apply(rule *database.CommentRule, userID uuid.UUID) error {
+	if r.Name == "" {
+		return errors.New("name required")
+	}
+	if !database.IsRuleAction(r.Action) {
+		return errors.New("action must be hold, delete, reply, tag or notify")
+	}
+	if r.TextPattern == "" && r.AuthorPattern == "" && r.AuthorChannelID == "" &&
+		r.VideoID == "" && r.MinLength == 0 && r.MaxLength == 0 && r.Language == "" {
+		return errors.New("at least one condition required")
+	}
+	if r.MinLength < 0 || r.MaxLength < 0 || (r.MaxLength > 0 && r.MinLength > r.MaxLength) {
+		return errors.New("invalid minLength or maxLength")
+	}
+	if _, err := regexp.Compile(r.TextPattern); err != nil {
+		return fmt.Errorf("invalid textPattern: %v", err)
+	}
+	if _, err := regexp.Compile(r.AuthorPattern); err != nil {
+		return fmt.Errorf("invalid authorPattern: %v", err)
+	}
+
+	rule.TemplateID = nil
+	switch r.Action {
+	case database.RuleReply:
+		if _, err := database.GetReplyTemplate(r.TemplateID, userID); err != nil {
+			return errors.New("templateId must be a reply template you can use")
+		}
+		templateID := uuid.MustParse(r.TemplateID)
+		rule.TemplateID = &templateID
+	case database.RuleTag:
+		if r.Tag == "" {
+			return errors.New("tag required")
+		}
+	}
+
+	rule.Name = r.Name
+	rule.Enabled = r.Enabled == nil || *r.Enabled
+	rule.Position = r.Position
+	rule.TextPattern = r.TextPattern
+	rule.AuthorPattern = r.AuthorPattern
+	rule.AuthorChannelID = r.AuthorChannelID
+	rule.VideoID = r.VideoID
+	rule.MinLength = r.MinLength
+	rule.MaxLength = r.MaxLength
+	rule.Language = r.Language
+	rule.Action = r.Action
+	rule.Tag = r.Tag
+	return nil
+}
+
+func compileRules(rules []database.CommentRule) ([]compiledRule, error) {
+	compiled := make([]compiledRule, 0, len(rules))
+	for _, rule := range rules {
+		cr := compiledRule{CommentRule: rule}
+
+		var err error
+		if rule.TextPattern != "" {
+			if cr.text, err = regexp.Compile(rule.TextPattern); err != nil {
+				return nil, fmt.Errorf("rule %s: %v", rule.Name, err)
+			}
+		}
+		if rule.AuthorPattern != "" {
+			if cr.author, err = regexp.Compile(rule.AuthorPattern); err != nil {
+				return nil, fmt.Errorf("rule %s: %v", rule.Name, err)
+			}
+		}
+
+		compiled = append(compiled, cr)
+	}
+	return compiled, nil
+}
+
+func (r compiledRule) matches(comment database.Comment) bool {
+	if r.VideoID != "" && r.VideoID != comment.VideoID {
+		return false
+	}
+	if r.AuthorChannelID != "" && r.AuthorChannelID != comment.AuthorChannelID {
+		return false
+	}
+
+	length := utf8.RuneCountInString(comment.TextOriginal)
+	if length < r.MinLength || (r.MaxLength > 0 && length > r.MaxLength) {
+		return false
+	}
+
+	if r.text != nil && !r.text.MatchString(comment.TextOriginal) {
+		return false
+	}
+	if r.author != nil && !r.author.MatchString(comment.AuthorDisplayName) {
+		return false
+	}
+
+	return r.Language == "" || r.Language == utils.DetectLanguage(comment.TextOriginal)
+}
+
+/*
+matchingRules
+- Returns the indexes of the rules that fire on the comment, in rule order
+- A delete ends the chain, nothing is left to act on
+- onlyNewer skips rules created after the comment was posted, so a new rule never touches old comments
+*/
+func matchingRules(rules []compiledRule, comment database.Comment, onlyNewer bool) []int {
+	// the channel's own comments are never moderated
+	if comment.AuthorChannelID != "" && comment.AuthorChannelID == comment.ChannelID {
+		return nil
+	}
+
+	matched := []int{}
+	for i, rule := range rules {
+		if onlyNewer && comment.PublishedAt.Before(rule.CreatedAt) {
+			continue
+		}
+		if !rule.matches(comment) {
+			continue
+		}
+
+		matched = append(matched, i)
+		if rule.Action == database.RuleDelete {
+			break
+		}
+	}
+	return matched
+}
+
+// runCommentRules applies the user's enabled rules to freshly synced comments
+// and returns how many actions fired
+func runCommentRules(userID uuid.UUID, token string, comments []database.Comment) (int, error) {
+	if len(comments) == 0 {
+		return 0, nil
+	}
+
+	rules, err := database.GetCommentRules(userID, true)
+	if err != nil || len(rules) == 0 {
+		return 0, err
+	}
+
+	compiled, err := compileRules(rules)
+	if err != nil {
+		return 0, err
+	}
+
+	fired := 0
+	for _, comment := range comments {
+		for _, i := range matchingRules(compiled, comment, true) {
+			rule := compiled[i].CommentRule
+
+			claimed, err := database.ClaimRuleHit(&database.CommentRuleHit{
+				RuleID:    rule.ID,
+				CommentID: comment.ID,
+				UserID:    userID,
+				VideoID:   comment.VideoID,
+				Action:    rule.Action,
+			})
+			if err != nil {
+				return fired, err
+			}
+			if !claimed {
+				continue
+			}
+
+			if err := applyRuleAction(rule, comment, userID, token); err != nil {
+				database.SetRuleHitError(rule.ID, comment.ID, err)
+				continue
+			}
+			fired++
+		}
+	}
+
+	return fired, nil
+}
+
+func applyRuleAction(rule database.CommentRule, comment database.Comment, userID uuid.UUID, token string) error {
+	switch rule.Action {
+	case database.RuleHold:
+		waitForYTWrite()
+		return setModerationStatus([]string{comment.ID}, "heldForReview", false, token)
+
+	case database.RuleDelete:
+		_, err := queueDeletion(comment.ID, userID, token)
+		return err
+
+	case database.RuleReply:
+		// YouTube only takes replies to top level comments
+		parentId := comment.ParentID
+		if parentId == "" {
+			parentId = comment.ID
+		}
+
+		text, err := renderReplyTemplate(rule.TemplateID.String(), userID, parentId, token, map[string]string{
+			"author":      comment.AuthorDisplayName,
+			"commentText": comment.TextOriginal,
+		})
+		if err != nil {
+			return err
+		}
+
+		waitForYTWrite()
+		if err := postReply(parentId, text, token); err != nil {
+			return err
+		}
+
+		database.SetTriageStatus(parentId, database.TriageAnswered, userID)
+		return nil
+
+	case database.RuleTag:
+		return database.AddCommentTag(comment.ID, rule.Tag)
+	}
+
+	// notify only needs the hit record
+	return nil
+}
+
+// markTags attaches rule tags to threads and replies
+func markTags(items []CommentThreadItem) error {
+	ids := []string{}
+	for _, item := range items {
+		ids = append(ids, item.Snippet.TopLevelComment.Id)
+		for _, reply := range item.Replies.Comments {
+			ids = append(ids, reply.Id)
+		}
+	}
+
+	tags, err := database.GetCommentTags(ids)
+	if err != nil {
+		return err
+	}
+
+	for i := range items {
+		items[i].Tags = tags[items[i].Snippet.TopLevelComment.Id]
+		for j := range items[i].Replies.Comments {
+			items[i].Replies.Comments[j].Tags = tags[items[i].Replies.Comments[j].Id]
+		}
+	}
+
+	return nil
+}
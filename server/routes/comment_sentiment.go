@@ -0,0 +1,174 @@
+package routes
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"yt_dashboard.com/database"
+	"yt_dashboard.com/utils"
+)
+
+const (
+	maxSentimentComments = 20000
+	defaultSentimentDays = 30
+)
+
+type SentimentSummary struct {
+	Comments int     `json:"comments"`
+	Positive int     `json:"positive"`
+	Neutral  int     `json:"neutral"`
+	Negative int     `json:"negative"`
+	Average  float64 `json:"average"`
+}
+
+type VideoSentiment struct {
+	VideoID string `json:"videoId"`
+	SentimentSummary
+}
+
+type DaySentiment struct {
+	Day string `json:"day"` // YYYY-MM-DD, UTC
+	SentimentSummary
+}
+
+func parseSentimentFilter(c *gin.Context) (string, error) {
+	filter := c.Query("sentiment")
+	if filter != "" && filter != utils.SentimentPositive && filter != utils.SentimentNeutral && filter != utils.SentimentNegative {
+		return "", fmt.Errorf("sentiment must be positive, neutral or negative")
+	}
+	return filter, nil
+}
+
+// applySentiment scores threads and replies, filter keeps threads whose top
+// level comment has that label
+func applySentiment(items []CommentThreadItem, filter string) []CommentThreadItem {
+	filtered := make([]CommentThreadItem, 0, len(items))
+	for _, item := range items {
+		s := utils.AnalyzeSentiment(item.Snippet.TopLevelComment.Snippet.TextOriginal)
+		item.Sentiment = &s
+
+		for j := range item.Replies.Comments {
+			s := utils.AnalyzeSentiment(item.Replies.Comments[j].Snippet.TextOriginal)
+			item.Replies.Comments[j].Sentiment = &s
+		}
+
+		if filter != "" && item.Sentiment.Label != filter {
+			continue
+		}
+		filtered = append(filtered, item)
+	}
+	return filtered
+}
+
+/*
+GetCommentSentiment
+- Scores synced comments of your channel, or of one video, over the last days
+- Returns the overall split, a per video breakdown and a daily series
+*/
+func GetCommentSentiment(c *gin.Context) {
+	tokenAny, exists := c.Get("accessToken")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "not authenticated"})
+		return
+	}
+	token := tokenAny.(string)
+
+	days, err := queryInt(c, "days", defaultSentimentDays, 0, 3650)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	channelId, err := getMyChannelID(token)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	since := time.Time{}
+	if days > 0 {
+		since = time.Now().AddDate(0, 0, -days)
+	}
+
+	comments, err := database.GetRecentComments(channelId, c.Query("videoId"), since, maxSentimentComments)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	overall := SentimentSummary{}
+	byVideo := map[string]*SentimentSummary{}
+	byDay := map[string]*SentimentSummary{}
+
+	for _, comment := range comments {
+		if comment.AuthorChannelID == channelId {
+			continue
+		}
+
+		s := utils.AnalyzeSentiment(comment.TextOriginal)
+		day := comment.PublishedAt.UTC().Format(time.DateOnly)
+
+		if byVideo[comment.VideoID] == nil {
+			byVideo[comment.VideoID] = &SentimentSummary{}
+		}
+		if byDay[day] == nil {
+			byDay[day] = &SentimentSummary{}
+		}
+
+		overall.add(s)
+		byVideo[comment.VideoID].add(s)
+		byDay[day].add(s)
+	}
+
+	videos := make([]VideoSentiment, 0, len(byVideo))
+	for videoId, summary := range byVideo {
+		videos = append(videos, VideoSentiment{VideoID: videoId, SentimentSummary: summary.finish()})
+	}
+	sort.Slice(videos, func(i, j int) bool {
+		if videos[i].Comments != videos[j].Comments {
+			return videos[i].Comments > videos[j].Comments
+		}
+		return videos[i].VideoID < videos[j].VideoID
+	})
+
+	series := make([]DaySentiment, 0, len(byDay))
+	for day, summary := range byDay {
+		series = append(series, DaySentiment{Day: day, SentimentSummary: summary.finish()})
+	}
+	sort.Slice(series, func(i, j int) bool {
+		return series[i].Day < series[j].Day
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"overall": overall.finish(),
+		"videos":  videos,
+		"days":    series,
+	})
+}
+
+// add counts a comment, Average holds the running sum until finish
+func (s *SentimentSummary) add(sentiment utils.Sentiment) {
+	s.Comments++
+	s.Average += sentiment.Score
+
+	switch sentiment.Label {
+	case utils.SentimentPositive:
+		s.Positive++
+	case utils.SentimentNegative:
+		s.Negative++
+	default:
+		s.Neutral++
+	}
+}
+
+func (s SentimentSummary) finish() SentimentSummary {
+	if s.Comments > 0 {
+		s.Average = math.Round(s.Average/float64(s.Comments)*100) / 100
+	}
+	return s
+}
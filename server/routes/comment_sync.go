@@ -0,0 +1,437 @@
+package routes
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"yt_dashboard.com/database"
+)
+
+const (
+	defaultSyncInterval     = 15 * time.Minute
+	defaultFullSyncInterval = 6 * time.Hour
+)
+
+type SyncResult struct {
+	VideoID  string    `json:"videoId"`
+	Full     bool      `json:"full"`
+	Threads  int       `json:"threads"`
+	Replies  int       `json:"replies"`
+	Removed  int64     `json:"removed"`
+	Blocked  int       `json:"blocked"`
+	RuleHits int       `json:"ruleHits"`
+	SyncedAt time.Time `json:"syncedAt"`
+}
+
+func envDuration(name string, fallback time.Duration) time.Duration {
+	if v := os.Getenv(name); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			return d
+		}
+	}
+	return fallback
+}
+
+// SyncComments syncs one video right away instead of waiting for the worker
+func SyncComments(c *gin.Context) {
+	tokenAny, exists := c.Get("accessToken")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "not authenticated"})
+		return
+	}
+	token := tokenAny.(string)
+
+	videoId := c.Query("videoId")
+	if videoId == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "videoId required"})
+		return
+	}
+
+	userID := c.MustGet("userID").(uuid.UUID)
+
+	// synced rows are shared, and the sync applies this user's blocklist and rules
+	owned, err := ownsVideo(userID.String(), videoId, token)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !owned {
+		c.JSON(http.StatusForbidden, gin.H{"error": "video is not on your channel"})
+		return
+	}
+
+	result, err := syncVideoComments(userID, videoId, token, c.Query("full") == "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+/*
+syncVideoComments
+- Full sync pages through every thread and drops local rows YouTube no longer has
+- Incremental sync walks threads newest first and stops at the first page with nothing new
+- New replies don't move old threads up, so it keeps walking while the video's comment count isn't accounted for
+- Replies are only refetched for threads whose reply count or top comment changed
+- A full sync is forced when the last one is older than COMMENT_FULL_SYNC_INTERVAL
+- Comments of changed threads then go through the user's blocklist and comment rules
+*/
+func syncVideoComments(userID uuid.UUID, videoId string, token string, full bool) (SyncResult, error) {
+	result := SyncResult{VideoID: videoId}
+
+	state, err := database.GetVideoSync(videoId)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return result, err
+	}
+	if time.Since(state.LastFullSyncAt) > envDuration("COMMENT_FULL_SYNC_INTERVAL", defaultFullSyncInterval) {
+		full = true
+	}
+	result.Full = full
+
+	stored, err := database.GetThreadStates(videoId)
+	if err != nil {
+		return result, err
+	}
+
+	// -1 when the statistic is hidden or unavailable, the walk then stops
+	// at the first unchanged page like before
+	videoComments := -1
+	if video, err := getVideo(videoId, token); err == nil {
+		if n, err := strconv.Atoi(video.Statistics.CommentCount); err == nil {
+			videoComments = n
+		}
+	}
+
+	startedAt := time.Now()
+	seen := []string{}
+	// comments added since the last sync that this walk has come across
+	found := 0
+	fresh := []database.Comment{}
+	channelId := state.ChannelID
+	pageToken := ""
+
+	for {
+		page, err := fetchComments(videoId, pageToken, token, CommentListOptions{Order: "time", MaxResults: 100})
+		if err != nil {
+			return result, err
+		}
+
+		changedOnPage := 0
+		for _, item := range page.Items {
+			seen = append(seen, item.Id)
+			channelId = item.Snippet.ChannelId
+
+			thread, comments := threadToRows(item, startedAt)
+
+			prev, known := stored[item.Id]
+			changed := !known ||
+				prev.TotalReplyCount != thread.TotalReplyCount ||
+				!prev.EditedAt.Equal(comments[0].EditedAt)
+			if changed {
+				changedOnPage++
+				if !known {
+					found += 1 + thread.TotalReplyCount
+				} else if thread.TotalReplyCount > prev.TotalReplyCount {
+					found += thread.TotalReplyCount - prev.TotalReplyCount
+				}
+			}
+
+			if changed {
+				// only threads with more replies than embedded cost extra calls
+				if len(item.Replies.Comments) < item.Snippet.TotalReplyCount {
+					replies, err := fetchAllReplies(item.Id, token)
+					if err != nil {
+						return result, err
+					}
+
+					comments = comments[:1]
+					for _, reply := range replies {
+						comments = append(comments, commentToRow(reply.Id, item, reply.Snippet, startedAt))
+					}
+				}
+
+				replyIds := make([]string, 0, len(comments)-1)
+				for _, reply := range comments[1:] {
+					replyIds = append(replyIds, reply.ID)
+				}
+
+				removed, err := database.DeleteMissingReplies(item.Id, replyIds)
+				if err != nil {
+					return result, err
+				}
+				result.Removed += removed
+				fresh = append(fresh, comments...)
+			}
+
+			if err := database.UpsertThread(&thread, comments); err != nil {
+				return result, err
+			}
+
+			result.Threads++
+			result.Replies += len(comments) - 1
+		}
+
+		caughtUp := videoComments < 0 || state.CommentCount+found >= videoComments
+		if page.NextPageToken == "" || (!full && changedOnPage == 0 && caughtUp) {
+			break
+		}
+		pageToken = page.NextPageToken
+	}
+
+	if full {
+		removed, err := database.DeleteMissingThreads(videoId, seen)
+		if err != nil {
+			return result, err
+		}
+		result.Removed += removed
+		state.LastFullSyncAt = startedAt
+	}
+
+	state.VideoID = videoId
+	state.ChannelID = channelId
+	state.LastSyncedAt = startedAt
+	if videoComments >= 0 {
+		state.CommentCount = videoComments
+	}
+	if err := database.SaveVideoSync(&state); err != nil {
+		return result, err
+	}
+
+	// failing moderation shouldn't fail the sync, the comments are stored already
+	if err := moderateFresh(&result, userID, token, fresh); err != nil {
+		fmt.Println("comment moderation:", videoId, err)
+	}
+
+	result.SyncedAt = startedAt
+	return result, nil
+}
+
+// moderateFresh runs the blocklist, then the comment rules on whatever is
+// left that isn't from an allowlisted author
+func moderateFresh(result *SyncResult, userID uuid.UUID, token string, fresh []database.Comment) error {
+	lists, err := database.GetAuthorLists(userID)
+	if err != nil {
+		return err
+	}
+
+	moderated, err := enforceBlocklist(lists, fresh, token)
+	result.Blocked = len(moderated)
+	if err != nil {
+		return err
+	}
+
+	remaining := make([]database.Comment, 0, len(fresh))
+	for _, comment := range fresh {
+		if !moderated[comment.ID] && lists[comment.AuthorChannelID].List != database.AuthorAllow {
+			remaining = append(remaining, comment)
+		}
+	}
+
+	result.RuleHits, err = runCommentRules(userID, token, remaining)
+	return err
+}
+
+// threadToRows flattens a commentThreads item, the top level comment comes first
+func threadToRows(item CommentThreadItem, syncedAt time.Time) (database.CommentThread, []database.Comment) {
+	thread := database.CommentThread{
+		ID:                item.Id,
+		ChannelID:         item.Snippet.ChannelId,
+		VideoID:           item.Snippet.VideoId,
+		TopLevelCommentID: item.Snippet.TopLevelComment.Id,
+		CanReply:          item.Snippet.CanReply,
+		IsPublic:          item.Snippet.IsPublic,
+		TotalReplyCount:   item.Snippet.TotalReplyCount,
+		SyncedAt:          syncedAt,
+	}
+
+	comments := []database.Comment{
+		commentToRow(item.Snippet.TopLevelComment.Id, item, item.Snippet.TopLevelComment.Snippet, syncedAt),
+	}
+	for _, reply := range item.Replies.Comments {
+		comments = append(comments, commentToRow(reply.Id, item, reply.Snippet, syncedAt))
+	}
+
+	return thread, comments
+}
+
+func commentToRow(id string, item CommentThreadItem, s CommentSnippet, syncedAt time.Time) database.Comment {
+	publishedAt, _ := time.Parse(time.RFC3339, s.PublishedAt)
+	editedAt, _ := time.Parse(time.RFC3339, s.UpdatedAt)
+
+	return database.Comment{
+		ID:                    id,
+		ThreadID:              item.Id,
+		ParentID:              s.ParentId,
+		ChannelID:             item.Snippet.ChannelId,
+		VideoID:               item.Snippet.VideoId,
+		AuthorDisplayName:     s.AuthorDisplayName,
+		AuthorProfileImageUrl: s.AuthorProfileImageUrl,
+		AuthorChannelUrl:      s.AuthorChannelUrl,
+		AuthorChannelID:       s.AuthorChannelId.Value,
+		TextOriginal:          s.TextOriginal,
+		TextDisplay:           s.TextDisplay,
+		CanRate:               s.CanRate,
+		LikeCount:             s.LikeCount,
+		PublishedAt:           publishedAt,
+		EditedAt:              editedAt,
+		SyncedAt:              syncedAt,
+	}
+}
+
+func rowToSnippet(row database.Comment) CommentSnippet {
+	s := CommentSnippet{
+		AuthorDisplayName:     row.AuthorDisplayName,
+		AuthorProfileImageUrl: row.AuthorProfileImageUrl,
+		AuthorChannelUrl:      row.AuthorChannelUrl,
+		TextOriginal:          row.TextOriginal,
+		TextDisplay:           row.TextDisplay,
+		VideoId:               row.VideoID,
+		ParentId:              row.ParentID,
+		CanRate:               row.CanRate,
+		LikeCount:             row.LikeCount,
+		PublishedAt:           row.PublishedAt.Format(time.RFC3339),
+		UpdatedAt:             row.EditedAt.Format(time.RFC3339),
+	}
+	s.AuthorChannelId.Value = row.AuthorChannelID
+	return s
+}
+
+// rowsToThreadItem rebuilds the YouTube shaped item from stored rows
+func rowsToThreadItem(thread database.CommentThread, comments []database.Comment) CommentThreadItem {
+	var item CommentThreadItem
+	item.Id = thread.ID
+	item.Snippet.ChannelId = thread.ChannelID
+	item.Snippet.VideoId = thread.VideoID
+	item.Snippet.CanReply = thread.CanReply
+	item.Snippet.TotalReplyCount = thread.TotalReplyCount
+	item.Snippet.IsPublic = thread.IsPublic
+	item.Replies.Comments = []ReplyComment{}
+
+	for _, row := range comments {
+		if row.ID == thread.TopLevelCommentID {
+			item.Snippet.TopLevelComment = TopLevelComment{Id: row.ID, Snippet: rowToSnippet(row)}
+			continue
+		}
+		item.Replies.Comments = append(item.Replies.Comments, ReplyComment{Id: row.ID, Snippet: rowToSnippet(row)})
+	}
+
+	return item
+}
+
+// getStoredComments serves GetCommentThread from the local store, the
+// pageToken is an offset since there is no YouTube cursor to hand back
+func getStoredComments(videoId string, pageToken string, opts CommentListOptions, sortByLikes bool) (*YTCommentThreadResponse, error) {
+	sync, err := database.GetVideoSync(videoId)
+	if err != nil {
+		return nil, err
+	}
+
+	offset := 0
+	if pageToken != "" {
+		offset, err = strconv.Atoi(pageToken)
+		if err != nil || offset < 0 {
+			return nil, fmt.Errorf("invalid pageToken")
+		}
+	}
+
+	limit := opts.MaxResults
+	if limit == 0 {
+		limit = 20
+	}
+
+	threads, comments, err := database.ListThreads(database.CommentQuery{
+		VideoID:     videoId,
+		SearchTerms: opts.SearchTerms,
+		SortByLikes: sortByLikes,
+		Offset:      offset,
+		Limit:       limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := &YTCommentThreadResponse{
+		Items:    make([]CommentThreadItem, 0, len(threads)),
+		Source:   "local",
+		SyncedAt: &sync.LastSyncedAt,
+	}
+	for _, thread := range threads {
+		res.Items = append(res.Items, rowsToThreadItem(thread, comments[thread.ID]))
+	}
+
+	if len(threads) == limit {
+		res.NextPageToken = strconv.Itoa(offset + limit)
+	}
+
+	return res, nil
+}
+
+/*
+StartCommentSync
+- Background worker syncing comments of every video of every signed in user
+- COMMENT_SYNC_INTERVAL sets the period, "0" disables the worker
+*/
+func StartCommentSync() {
+	interval := envDuration("COMMENT_SYNC_INTERVAL", defaultSyncInterval)
+	if interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			syncAllChannels()
+		}
+	}()
+}
+
+func syncAllChannels() {
+	users, err := database.GetUsersWithTokens()
+	if err != nil {
+		fmt.Println("comment sync: cant list users:", err)
+		return
+	}
+
+	for _, user := range users {
+		token, err := accessTokenFor(user.GoogleUserID)
+		if err != nil {
+			fmt.Println("comment sync: no token for", user.ID, err)
+			continue
+		}
+
+		uploads, err := getChannelUploads(token)
+		if err != nil {
+			fmt.Println("comment sync: cant list uploads for", user.ID, err)
+			continue
+		}
+
+		for videoId := range uploads {
+			if _, err := syncVideoComments(user.ID, videoId, token, false); err != nil {
+				fmt.Println("comment sync:", videoId, err)
+			}
+		}
+	}
+}
+
+// accessTokenFor is the middleware's token flow for code without a request
+func accessTokenFor(googleUserId string) (string, error) {
+	refreshToken_, err := database.GetToken(googleUserId)
+	if err != nil {
+		return "", err
+	}
+
+	// cached tokens may have expired, background jobs always refresh
+	return refreshToken(refreshToken_, googleUserId)
+}
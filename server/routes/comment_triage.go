@@ -0,0 +1,148 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"yt_dashboard.com/database"
+)
+
+var errInvalidTriageFilter = errors.New("status must be a triage state and assignee me, none or a user email")
+
+type TriageRequest struct {
+	CommentIDs    []string `json:"commentIds"`
+	Status        string   `json:"status"`
+	AssigneeEmail string   `json:"assigneeEmail"` // empty leaves it unassigned
+}
+
+// triageFilter narrows comment listings, zero value matches everything
+type triageFilter struct {
+	Status     string
+	Assignee   *uuid.UUID
+	Unassigned bool
+}
+
+func UpdateTriage(c *gin.Context) {
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "not authenticated"})
+		return
+	}
+	userID := userIDAny.(uuid.UUID)
+
+	var body TriageRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	if len(body.CommentIDs) == 0 || !database.IsTriageStatus(body.Status) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "commentIds and a valid status required"})
+		return
+	}
+
+	var assignee *uuid.UUID
+	if body.AssigneeEmail != "" {
+		user, err := database.GetUserByEmail(body.AssigneeEmail)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "no dashboard user with that email"})
+			return
+		}
+		assignee = &user.ID
+	}
+
+	triages := make([]database.CommentTriage, 0, len(body.CommentIDs))
+	for _, commentId := range body.CommentIDs {
+		triage := database.CommentTriage{
+			CommentID:  commentId,
+			Status:     body.Status,
+			AssigneeID: assignee,
+			UpdatedBy:  userID,
+		}
+
+		if err := database.UpsertTriage(&triage); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		triages = append(triages, triage)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": triages})
+}
+
+/*
+parseTriageFilter
+- status: one of the triage states
+- assignee: "me", "none" or a dashboard user's email
+*/
+func parseTriageFilter(c *gin.Context, userID uuid.UUID) (triageFilter, error) {
+	filter := triageFilter{Status: c.Query("status")}
+
+	if filter.Status != "" && !database.IsTriageStatus(filter.Status) {
+		return filter, errInvalidTriageFilter
+	}
+
+	switch assignee := c.Query("assignee"); assignee {
+	case "":
+	case "me":
+		filter.Assignee = &userID
+	case "none":
+		filter.Unassigned = true
+	default:
+		user, err := database.GetUserByEmail(assignee)
+		if err != nil {
+			return filter, errInvalidTriageFilter
+		}
+		filter.Assignee = &user.ID
+	}
+
+	return filter, nil
+}
+
+func (f triageFilter) matches(triage *database.CommentTriage) bool {
+	status := database.TriageNew
+	var assignee *uuid.UUID
+	if triage != nil {
+		status = triage.Status
+		assignee = triage.AssigneeID
+	}
+
+	if f.Status != "" && f.Status != status {
+		return false
+	}
+	if f.Unassigned && assignee != nil {
+		return false
+	}
+	if f.Assignee != nil && (assignee == nil || *assignee != *f.Assignee) {
+		return false
+	}
+	return true
+}
+
+// applyTriage attaches triage state to each thread and drops the ones not matching filter
+func applyTriage(items []CommentThreadItem, filter triageFilter) ([]CommentThreadItem, error) {
+	ids := make([]string, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.Snippet.TopLevelComment.Id)
+	}
+
+	triages, err := database.GetTriages(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	kept := items[:0]
+	for _, item := range items {
+		if triage, ok := triages[item.Snippet.TopLevelComment.Id]; ok {
+			item.Triage = &triage
+		}
+		if filter.matches(item.Triage) {
+			kept = append(kept, item)
+		}
+	}
+
+	return kept, nil
+}
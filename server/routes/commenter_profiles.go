@@ -0,0 +1,198 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"yt_dashboard.com/database"
+)
+
+const (
+	defaultProfileComments = 20
+	maxProfileComments     = 100
+)
+
+type CommenterProfileRequest struct {
+	AuthorChannelID string   `json:"authorChannelId"`
+	Note            string   `json:"note"`
+	Labels          []string `json:"labels"`
+}
+
+type CommenterProfileResponse struct {
+	AuthorChannelID       string                      `json:"authorChannelId"`
+	AuthorDisplayName     string                      `json:"authorDisplayName"`
+	AuthorProfileImageUrl string                      `json:"authorProfileImageUrl"`
+	AuthorChannelUrl      string                      `json:"authorChannelUrl"`
+	Stats                 database.AuthorStats        `json:"stats"`
+	Videos                []database.AuthorVideoCount `json:"videos"`
+	RecentComments        []TopLevelComment           `json:"recentComments"`
+	Note                  string                      `json:"note"`
+	Labels                []string                    `json:"labels"`
+	AuthorList            *database.AuthorListEntry   `json:"authorList,omitempty"`
+}
+
+/*
+GetCommenterProfile
+- Aggregates a commenter's stored comments across the videos of your channel
+- Adds your private note and labels and their blocklist / allowlist entry
+- Only synced videos count, see POST /comments/sync
+*/
+func GetCommenterProfile(c *gin.Context) {
+	tokenAny, exists := c.Get("accessToken")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "not authenticated"})
+		return
+	}
+	token := tokenAny.(string)
+
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "not authenticated"})
+		return
+	}
+	userID := userIDAny.(uuid.UUID)
+
+	authorChannelId := c.Query("authorChannelId")
+	if authorChannelId == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "authorChannelId required"})
+		return
+	}
+
+	limit := defaultProfileComments
+	if l := c.Query("limit"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil || n < 1 || n > maxProfileComments {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 100"})
+			return
+		}
+		limit = n
+	}
+
+	channelId, err := getMyChannelID(token)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	res := CommenterProfileResponse{
+		AuthorChannelID: authorChannelId,
+		RecentComments:  []TopLevelComment{},
+		Labels:          []string{},
+	}
+
+	res.Stats, err = database.GetAuthorStats(channelId, authorChannelId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	res.Videos, err = database.GetAuthorVideos(channelId, authorChannelId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	comments, err := database.GetAuthorComments(channelId, authorChannelId, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	for _, row := range comments {
+		res.RecentComments = append(res.RecentComments, TopLevelComment{Id: row.ID, Snippet: rowToSnippet(row)})
+	}
+
+	// names and avatars change, the newest comment has the current ones
+	if len(comments) > 0 {
+		res.AuthorDisplayName = comments[0].AuthorDisplayName
+		res.AuthorProfileImageUrl = comments[0].AuthorProfileImageUrl
+		res.AuthorChannelUrl = comments[0].AuthorChannelUrl
+	}
+
+	profile, err := database.GetCommenterProfile(userID, authorChannelId)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	res.Note = profile.Note
+	if profile.Labels != nil {
+		res.Labels = profile.Labels
+	}
+
+	lists, err := database.GetAuthorLists(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if entry, ok := lists[authorChannelId]; ok {
+		res.AuthorList = &entry
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func UpdateCommenterProfile(c *gin.Context) {
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "not authenticated"})
+		return
+	}
+	userID := userIDAny.(uuid.UUID)
+
+	var body CommenterProfileRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	if body.AuthorChannelID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "authorChannelId required"})
+		return
+	}
+
+	labels := []string{}
+	for _, label := range body.Labels {
+		label = strings.TrimSpace(label)
+		if label != "" && !slices.Contains(labels, label) {
+			labels = append(labels, label)
+		}
+	}
+
+	profile := database.CommenterProfile{
+		UserID:          userID,
+		AuthorChannelID: body.AuthorChannelID,
+		Note:            body.Note,
+		Labels:          labels,
+	}
+
+	if err := database.SaveCommenterProfile(&profile); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
+
+// GetCommenterProfiles lists the commenters you annotated
+func GetCommenterProfiles(c *gin.Context) {
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "not authenticated"})
+		return
+	}
+	userID := userIDAny.(uuid.UUID)
+
+	profiles, err := database.GetCommenterProfiles(userID, c.Query("label"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": profiles})
+}
@@ -1,12 +1,21 @@
 package routes
 
 import (
+	"errors"
 	"io"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"yt_dashboard.com/database"
 )
 
+const defaultDeleteGrace = 30 * time.Second
+
+// DeleteComment queues the deletion for COMMENT_DELETE_GRACE so it can be
+// undone, a grace of 0 deletes right away
 func DeleteComment(c *gin.Context) {
 	commentId := c.Query("commentId")
 	if commentId == "" {
@@ -21,22 +30,63 @@ func DeleteComment(c *gin.Context) {
 	}
 	token := tokenAny.(string)
 
+	deletion, err := queueDeletion(commentId, c.MustGet("userID").(uuid.UUID), token)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	if deletion == nil {
+		c.JSON(200, gin.H{"status": "comment deleted"})
+		return
+	}
+
+	c.JSON(200, gin.H{"status": "comment deletion scheduled", "deletion": deletion})
+}
+
+// queueDeletion returns the pending deletion, or nil when the grace is 0 and
+// the comment was deleted right away
+func queueDeletion(commentId string, userID uuid.UUID, token string) (*database.PendingDeletion, error) {
+	grace := envDuration("COMMENT_DELETE_GRACE", defaultDeleteGrace)
+	if grace <= 0 {
+		waitForYTWrite()
+		if err := deleteComment(commentId, token); err != nil {
+			return nil, err
+		}
+
+		database.DeleteStoredComment(commentId)
+		return nil, nil
+	}
+
+	deletion := database.PendingDeletion{
+		CommentID: commentId,
+		UserID:    userID,
+		Status:    database.DeletionPending,
+		ExecuteAt: time.Now().Add(grace),
+	}
+
+	if err := database.InsertPendingDeletion(&deletion); err != nil {
+		return nil, err
+	}
+
+	return &deletion, nil
+}
+
+func deleteComment(commentId string, token string) error {
 	reqURL := "https://www.googleapis.com/youtube/v3/comments?id=" + commentId
 	req, _ := http.NewRequest(http.MethodDelete, reqURL, nil)
 	req.Header.Set("Authorization", "Bearer "+token)
 
 	res, err := http.DefaultClient.Do(req)
 	if err != nil {
-		c.JSON(500, gin.H{"error": err.Error()})
-		return
+		return err
 	}
 	defer res.Body.Close()
 
 	if res.StatusCode != http.StatusNoContent {
 		b, _ := io.ReadAll(res.Body)
-		c.JSON(500, gin.H{"error": string(b)})
-		return
+		return errors.New(string(b))
 	}
 
-	c.JSON(200, gin.H{"status": "comment deleted"})
+	return nil
 }
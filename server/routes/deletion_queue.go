@@ -0,0 +1,141 @@
+package routes
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"yt_dashboard.com/database"
+)
+
+const (
+	deletionWorkerInterval = 5 * time.Second
+	// running deletions older than this were claimed by a worker that died
+	deletionLease = 5 * time.Minute
+)
+
+type UndoDeleteRequest struct {
+	ID string `json:"id"`
+}
+
+func UndoDeleteComment(c *gin.Context) {
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "not authenticated"})
+		return
+	}
+	userID := userIDAny.(uuid.UUID)
+
+	var body UndoDeleteRequest
+	if err := c.ShouldBindJSON(&body); err != nil || body.ID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id required"})
+		return
+	}
+
+	err := database.CancelDeletion(body.ID, userID)
+	if errors.Is(err, database.ErrDeletionNotPending) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "deletion cancelled"})
+}
+
+func GetPendingDeletions(c *gin.Context) {
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "not authenticated"})
+		return
+	}
+	userID := userIDAny.(uuid.UUID)
+
+	deletions, err := database.GetPendingDeletions(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": deletions})
+}
+
+// markPendingDeletions flags threads and replies that are about to be deleted
+func markPendingDeletions(items []CommentThreadItem) error {
+	ids := []string{}
+	for _, item := range items {
+		ids = append(ids, item.Snippet.TopLevelComment.Id)
+		for _, reply := range item.Replies.Comments {
+			ids = append(ids, reply.Id)
+		}
+	}
+
+	pending, err := database.GetPendingDeletionsFor(ids)
+	if err != nil {
+		return err
+	}
+
+	for i := range items {
+		if d, ok := pending[items[i].Snippet.TopLevelComment.Id]; ok {
+			items[i].PendingDeletion = &d
+		}
+		for j := range items[i].Replies.Comments {
+			if d, ok := pending[items[i].Replies.Comments[j].Id]; ok {
+				items[i].Replies.Comments[j].PendingDeletion = &d
+			}
+		}
+	}
+
+	return nil
+}
+
+// StartDeletionWorker executes queued deletions once their undo window is over
+func StartDeletionWorker() {
+	go func() {
+		ticker := time.NewTicker(deletionWorkerInterval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			runDueDeletions()
+		}
+	}()
+}
+
+func runDueDeletions() {
+	due, err := database.ClaimDueDeletions(time.Now(), deletionLease)
+	if err != nil {
+		fmt.Println("deletion worker:", err)
+	}
+
+	// one token per user per run, not per comment
+	tokens := map[uuid.UUID]string{}
+
+	for _, deletion := range due {
+		token, ok := tokens[deletion.UserID]
+		if !ok {
+			user, err := database.GetUserById(deletion.UserID)
+			if err == nil {
+				token, err = accessTokenFor(user.GoogleUserID)
+			}
+			if err != nil {
+				database.FinishDeletion(deletion.ID, err)
+				continue
+			}
+			tokens[deletion.UserID] = token
+		}
+
+		// a bulk delete can make hundreds of deletions due at once
+		waitForYTWrite()
+		err := deleteComment(deletion.CommentID, token)
+		if err == nil {
+			database.DeleteStoredComment(deletion.CommentID)
+		}
+		database.FinishDeletion(deletion.ID, err)
+	}
+}
@@ -0,0 +1,314 @@
+package routes
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"yt_dashboard.com/database"
+)
+
+const maxGiveawayWinners = 100
+
+type GiveawayRequest struct {
+	VideoID        string     `json:"videoId"`
+	Winners        int        `json:"winners"`
+	Keyword        string     `json:"keyword"`
+	EndsAt         *time.Time `json:"endsAt"`
+	OnePerAuthor   *bool      `json:"onePerAuthor"`
+	ExcludeBlocked *bool      `json:"excludeBlocked"`
+}
+
+type DrawGiveawayRequest struct {
+	ID string `json:"id"`
+}
+
+type GiveawayWinner struct {
+	CommentID         string `json:"commentId"`
+	AuthorChannelID   string `json:"authorChannelId"`
+	AuthorDisplayName string `json:"authorDisplayName"`
+	TextOriginal      string `json:"textOriginal"`
+	PublishedAt       string `json:"publishedAt"`
+	Ticket            string `json:"ticket"`
+}
+
+/*
+CreateGiveaway
+- Fixes the rules and commits to a random seed before anyone can see who entered
+- Only seedHash is returned, publish it with the giveaway announcement
+- endsAt must be in the future, the winners are drawn with DrawGiveaway once it passed
+*/
+func CreateGiveaway(c *gin.Context) {
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "not authenticated"})
+		return
+	}
+	userID := userIDAny.(uuid.UUID)
+
+	var body GiveawayRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	if body.VideoID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "videoId required"})
+		return
+	}
+	if body.Winners < 1 || body.Winners > maxGiveawayWinners {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "winners must be between 1 and 100"})
+		return
+	}
+	if body.EndsAt == nil || !body.EndsAt.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "endsAt must be in the future"})
+		return
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "cant generate seed"})
+		return
+	}
+	seed := hex.EncodeToString(b)
+	seedHash := sha256.Sum256([]byte(seed))
+
+	giveaway := database.Giveaway{
+		UserID:         userID,
+		VideoID:        body.VideoID,
+		Seed:           seed,
+		SeedHash:       hex.EncodeToString(seedHash[:]),
+		Winners:        body.Winners,
+		Keyword:        body.Keyword,
+		EndsAt:         body.EndsAt,
+		OnePerAuthor:   body.OnePerAuthor == nil || *body.OnePerAuthor,
+		ExcludeBlocked: body.ExcludeBlocked == nil || *body.ExcludeBlocked,
+		WinnerIDs:      []string{},
+	}
+
+	if err := database.InsertGiveaway(&giveaway); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"giveaway": hideUndrawnSeed(giveaway)})
+}
+
+/*
+DrawGiveaway
+- Pages through every top level comment of the video straight from YouTube
+- Filters: keyword, endsAt cutoff, one entry per author (their first), blocklisted authors
+- Draws with drawWinners, then stores the entry ids and winners and reveals the seed
+*/
+func DrawGiveaway(c *gin.Context) {
+	tokenAny, exists := c.Get("accessToken")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "not authenticated"})
+		return
+	}
+	token := tokenAny.(string)
+
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "not authenticated"})
+		return
+	}
+	userID := userIDAny.(uuid.UUID)
+
+	var body DrawGiveawayRequest
+	if err := c.ShouldBindJSON(&body); err != nil || body.ID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id required"})
+		return
+	}
+
+	giveaway, err := database.GetGiveaway(body.ID, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "giveaway not found"})
+		return
+	}
+	if giveaway.DrawnAt != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": database.ErrGiveawayDrawn.Error()})
+		return
+	}
+	if giveaway.EndsAt != nil && time.Now().Before(*giveaway.EndsAt) {
+		c.JSON(http.StatusConflict, gin.H{"error": "giveaway hasn't ended yet"})
+		return
+	}
+
+	var lists map[string]database.AuthorListEntry
+	if giveaway.ExcludeBlocked {
+		lists, err = database.GetAuthorLists(userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	entries := []TopLevelComment{}
+	firstByAuthor := map[string]int{}
+	pageToken := ""
+
+	for {
+		page, err := fetchComments(giveaway.VideoID, pageToken, token, CommentListOptions{Order: "time", MaxResults: 100})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		for _, item := range page.Items {
+			comment := item.Snippet.TopLevelComment
+			author := comment.Snippet.AuthorChannelId.Value
+
+			if !giveawayEligible(giveaway, comment.Snippet, item.Snippet.ChannelId, lists) {
+				continue
+			}
+
+			// pages are newest first, so a later entry by the same author is an earlier comment
+			if i, ok := firstByAuthor[author]; ok && giveaway.OnePerAuthor {
+				entries[i] = comment
+				continue
+			}
+
+			firstByAuthor[author] = len(entries)
+			entries = append(entries, comment)
+		}
+
+		if page.NextPageToken == "" {
+			break
+		}
+		pageToken = page.NextPageToken
+	}
+
+	ids := make([]string, len(entries))
+	byId := make(map[string]TopLevelComment, len(entries))
+	for i, entry := range entries {
+		ids[i] = entry.Id
+		byId[entry.Id] = entry
+	}
+
+	sort.Strings(ids)
+	hash := sha256.Sum256([]byte(strings.Join(ids, "\n")))
+	giveaway.Entries = len(ids)
+	giveaway.EntriesHash = hex.EncodeToString(hash[:])
+	giveaway.EntryIDs = ids
+
+	winners := []GiveawayWinner{}
+	giveaway.WinnerIDs = []string{}
+	for _, ticket := range drawWinners(giveaway.Seed, ids, giveaway.Winners) {
+		entry := byId[ticket.commentId]
+		giveaway.WinnerIDs = append(giveaway.WinnerIDs, entry.Id)
+		winners = append(winners, GiveawayWinner{
+			CommentID:         entry.Id,
+			AuthorChannelID:   entry.Snippet.AuthorChannelId.Value,
+			AuthorDisplayName: entry.Snippet.AuthorDisplayName,
+			TextOriginal:      entry.Snippet.TextOriginal,
+			PublishedAt:       entry.Snippet.PublishedAt,
+			Ticket:            ticket.value,
+		})
+	}
+
+	now := time.Now()
+	giveaway.DrawnAt = &now
+
+	err = database.SaveGiveawayDraw(&giveaway)
+	if errors.Is(err, database.ErrGiveawayDrawn) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"giveaway": giveaway,
+		"winners":  winners,
+	})
+}
+
+// GetGiveaways lists giveaways with their entries, seeds of undrawn ones stay hidden
+func GetGiveaways(c *gin.Context) {
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "not authenticated"})
+		return
+	}
+	userID := userIDAny.(uuid.UUID)
+
+	giveaways, err := database.GetGiveaways(userID, c.Query("videoId"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	for i := range giveaways {
+		giveaways[i] = hideUndrawnSeed(giveaways[i])
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": giveaways})
+}
+
+// hideUndrawnSeed blanks the seed until the draw, revealing it earlier would
+// let anyone predict the winners
+func hideUndrawnSeed(g database.Giveaway) database.Giveaway {
+	if g.DrawnAt == nil {
+		g.Seed = ""
+	}
+	return g
+}
+
+// giveawayEligible applies the filters that only need the comment itself
+func giveawayEligible(g database.Giveaway, s CommentSnippet, channelId string, lists map[string]database.AuthorListEntry) bool {
+	author := s.AuthorChannelId.Value
+	if author == "" || author == channelId {
+		return false
+	}
+	if g.ExcludeBlocked && lists[author].List == database.AuthorBlock {
+		return false
+	}
+	if g.Keyword != "" && !strings.Contains(strings.ToLower(s.TextOriginal), strings.ToLower(g.Keyword)) {
+		return false
+	}
+	if g.EndsAt != nil {
+		publishedAt, err := time.Parse(time.RFC3339, s.PublishedAt)
+		if err != nil || publishedAt.After(*g.EndsAt) {
+			return false
+		}
+	}
+	return true
+}
+
+type giveawayTicket struct {
+	commentId string
+	value     string
+}
+
+/*
+drawWinners
+- Every entry's ticket is hex(HMAC-SHA256(key: seed, message: comment id))
+- Winners are the n lowest tickets, ties can't happen in practice
+- The order of ids doesn't matter, so the draw is repeatable from the seed and the entry list alone
+*/
+func drawWinners(seed string, ids []string, n int) []giveawayTicket {
+	tickets := make([]giveawayTicket, len(ids))
+	for i, id := range ids {
+		mac := hmac.New(sha256.New, []byte(seed))
+		mac.Write([]byte(id))
+		tickets[i] = giveawayTicket{commentId: id, value: hex.EncodeToString(mac.Sum(nil))}
+	}
+
+	sort.Slice(tickets, func(i, j int) bool {
+		return tickets[i].value < tickets[j].value
+	})
+
+	return tickets[:min(n, len(tickets))]
+}
@@ -0,0 +1,86 @@
+package routes
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func giveawayEntries(n int) []string {
+	ids := make([]string, n)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("Ugz%04dcomment", i)
+	}
+	return ids
+}
+
+func winnerIds(tickets []giveawayTicket) []string {
+	ids := make([]string, len(tickets))
+	for i, t := range tickets {
+		ids[i] = t.commentId
+	}
+	return ids
+}
+
+func TestDrawWinnersIsReproducible(t *testing.T) {
+	tests := []struct {
+		name    string
+		seed    string
+		entries int
+		winners int
+	}{
+		{name: "one winner", seed: "4f2a9c", entries: 50, winners: 1},
+		{name: "several winners", seed: "published seed", entries: 500, winners: 10},
+		{name: "more winners than entries", seed: "4f2a9c", entries: 3, winners: 5},
+		{name: "no entries", seed: "4f2a9c", entries: 0, winners: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ids := giveawayEntries(tt.entries)
+			first := drawWinners(tt.seed, ids, tt.winners)
+
+			if want := min(tt.winners, tt.entries); len(first) != want {
+				t.Fatalf("got %d winners, want %d", len(first), want)
+			}
+
+			// anyone repeating the draw from the published list, in any order, gets the same result
+			shuffled := slices.Clone(ids)
+			slices.Reverse(shuffled)
+			for _, entries := range [][]string{ids, shuffled} {
+				again := drawWinners(tt.seed, entries, tt.winners)
+				if !reflect.DeepEqual(again, first) {
+					t.Errorf("redraw = %v, want %v", winnerIds(again), winnerIds(first))
+				}
+			}
+
+			for _, ticket := range first {
+				mac := hmac.New(sha256.New, []byte(tt.seed))
+				mac.Write([]byte(ticket.commentId))
+				if want := hex.EncodeToString(mac.Sum(nil)); ticket.value != want {
+					t.Errorf("ticket of %s = %s, want %s", ticket.commentId, ticket.value, want)
+				}
+			}
+
+			for i := 1; i < len(first); i++ {
+				if first[i-1].value > first[i].value {
+					t.Errorf("winners not ordered by ticket: %s before %s", first[i-1].value, first[i].value)
+				}
+			}
+		})
+	}
+}
+
+func TestDrawWinnersDependsOnSeed(t *testing.T) {
+	ids := giveawayEntries(500)
+
+	a := winnerIds(drawWinners("seed one", ids, 10))
+	b := winnerIds(drawWinners("seed two", ids, 10))
+	if reflect.DeepEqual(a, b) {
+		t.Errorf("different seeds drew the same winners %v", a)
+	}
+}
@@ -0,0 +1,123 @@
+package routes
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"yt_dashboard.com/database"
+)
+
+const (
+	defaultIdempotencyTTL = 24 * time.Hour
+	// a key still in progress after this belongs to a request that died
+	idempotencyLease = 5 * time.Minute
+	// set by handlers whose 5xx must be kept, see respondWriteError
+	keepIdempotentResponse = "keepIdempotentResponse"
+)
+
+// recordingWriter keeps a copy of the response so it can be replayed
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+/*
+Idempotent
+- Honors the Idempotency-Key header, requests without it pass straight through
+- A replay with the same key and body gets the stored response back
+- The same key with a different body is rejected, as is a replay while the first is running
+- 5xx responses are not stored so a failed request can be retried with the same key
+- Unless the handler flagged that its YouTube write may have gone through, see respondWriteError
+- A key left in progress for longer than idempotencyLease can be taken over
+- Must run after VerifyUser, keys are scoped per user
+*/
+func Idempotent() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader("Idempotency-Key")
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		userID := c.MustGet("userID").(uuid.UUID)
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "cant read request body"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		sum := sha256.Sum256([]byte(c.Request.Method + " " + c.Request.URL.RequestURI() + "\n" + string(body)))
+		hash := hex.EncodeToString(sum[:])
+
+		ttl := envDuration("IDEMPOTENCY_TTL", defaultIdempotencyTTL)
+		existing, err := database.ReserveIdempotencyKey(userID, key, hash, ttl, idempotencyLease)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		if existing != nil {
+			switch {
+			case existing.RequestHash != hash:
+				c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{
+					"error": "Idempotency-Key was already used for a different request",
+				})
+			case existing.Status == 0:
+				c.AbortWithStatusJSON(http.StatusConflict, gin.H{
+					"error": "a request with this Idempotency-Key is still in progress",
+				})
+			default:
+				c.Header("Idempotent-Replayed", "true")
+				c.Data(existing.Status, existing.ContentType, existing.Body)
+				c.Abort()
+			}
+			return
+		}
+
+		writer := &recordingWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+
+		c.Next()
+
+		status := writer.Status()
+		if status >= http.StatusInternalServerError && !c.GetBool(keepIdempotentResponse) {
+			database.ReleaseIdempotencyKey(userID, key)
+			return
+		}
+
+		database.CompleteIdempotencyKey(userID, key, status, writer.Header().Get("Content-Type"), writer.body.Bytes())
+	}
+}
+
+// respondWriteError answers a failed YouTube write, when it may have gone
+// through the response is kept for the Idempotency-Key so a retry can't post twice
+func respondWriteError(c *gin.Context, err error) {
+	if classifyWriteError(err) != writeUnknown {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Set(keepIdempotentResponse, true)
+	c.JSON(http.StatusBadGateway, gin.H{
+		"error": "YouTube didn't confirm the write, check before retrying with a new Idempotency-Key: " + err.Error(),
+	})
+}
@@ -0,0 +1,127 @@
+package routes
+
+import (
+	"encoding/csv"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"yt_dashboard.com/database"
+)
+
+const (
+	defaultLeaderboardDays  = 30
+	defaultLeaderboardLimit = 50
+	maxLeaderboardLimit     = 1000
+)
+
+/*
+GetLeaderboard
+- Ranks commenters by comments, likes received or videos commented on
+- Built from synced comments of your channel, over the last days (0 for all time)
+- format=csv downloads the same rows as a spreadsheet
+*/
+func GetLeaderboard(c *gin.Context) {
+	tokenAny, exists := c.Get("accessToken")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "not authenticated"})
+		return
+	}
+	token := tokenAny.(string)
+
+	q := database.LeaderboardQuery{
+		VideoID: c.Query("videoId"),
+		SortBy:  c.DefaultQuery("sort", "comments"),
+		Limit:   defaultLeaderboardLimit,
+	}
+
+	if q.SortBy != "comments" && q.SortBy != "likes" && q.SortBy != "videos" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sort must be comments, likes or videos"})
+		return
+	}
+
+	days := defaultLeaderboardDays
+	if d := c.Query("days"); d != "" {
+		n, err := strconv.Atoi(d)
+		if err != nil || n < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "days must be 0 or more"})
+			return
+		}
+		days = n
+	}
+	if days > 0 {
+		q.Since = time.Now().AddDate(0, 0, -days)
+	}
+
+	if l := c.Query("limit"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil || n < 1 || n > maxLeaderboardLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 1000"})
+			return
+		}
+		q.Limit = n
+	}
+
+	format := c.Query("format")
+	if format != "" && format != "json" && format != "csv" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be json or csv"})
+		return
+	}
+
+	channelId, err := getMyChannelID(token)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	q.ChannelID = channelId
+
+	entries, err := database.GetLeaderboard(q)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if format == "csv" {
+		writeLeaderboardCSV(c, entries)
+		return
+	}
+
+	res := gin.H{"items": entries, "sort": q.SortBy}
+	if !q.Since.IsZero() {
+		res["since"] = q.Since
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func writeLeaderboardCSV(c *gin.Context, entries []database.LeaderboardEntry) {
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", `attachment; filename="leaderboard.csv"`)
+	c.Status(http.StatusOK)
+
+	w := csv.NewWriter(c.Writer)
+	w.Write([]string{"rank", "authorChannelId", "authorDisplayName", "comments", "likes", "videos", "lastSeen"})
+	for _, e := range entries {
+		w.Write([]string{
+			strconv.Itoa(e.Rank),
+			e.AuthorChannelID,
+			csvSafe(e.AuthorDisplayName),
+			strconv.Itoa(e.Comments),
+			strconv.Itoa(e.Likes),
+			strconv.Itoa(e.Videos),
+			e.LastSeen.Format(time.RFC3339),
+		})
+	}
+	w.Flush()
+}
+
+// csvSafe keeps spreadsheet apps from running names that look like formulas,
+// the leading characters are the ones OWASP lists for CSV injection
+func csvSafe(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
@@ -63,7 +63,16 @@ func VerifyUser() gin.HandlerFunc {
 			}
 		}
 
+		user, err := database.GetUserByGoogleId(userId)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": "User not registered",
+			})
+			return
+		}
+
 		c.Set("accessToken", token)
+		c.Set("userID", user.ID)
 		c.Next()
 	}
 }
@@ -0,0 +1,249 @@
+package routes
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"yt_dashboard.com/database"
+	"yt_dashboard.com/storage"
+)
+
+const defaultMaxAttachmentBytes = 25 << 20
+
+// storyboards, scripts and audio clips
+var allowedAttachmentTypes = map[string]bool{
+	"image/png":       true,
+	"image/jpeg":      true,
+	"image/gif":       true,
+	"image/webp":      true,
+	"application/pdf": true,
+	"audio/mpeg":      true,
+	"audio/wave":      true,
+	"audio/aiff":      true,
+	"application/ogg": true,
+}
+
+func maxAttachmentBytes() int64 {
+	if v := os.Getenv("NOTE_ATTACHMENT_MAX_BYTES"); v != "" {
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil && n > 0 {
+			return n
+		}
+	}
+	return defaultMaxAttachmentBytes
+}
+
+func UploadAttachment(c *gin.Context) {
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "not authenticated"})
+		return
+	}
+	userID := userIDAny.(uuid.UUID)
+
+	noteId := c.Query("noteId")
+	if noteId == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "noteId required"})
+		return
+	}
+
+	// collaborators with write access can attach files too
+	note, access, err := database.GetNoteAccess(noteId, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "note not found"})
+		return
+	}
+
+	if access != database.ShareWrite {
+		c.JSON(http.StatusForbidden, gin.H{"error": "read only access"})
+		return
+	}
+
+	maxBytes := maxAttachmentBytes()
+	// leave some room for the multipart envelope
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes+1<<20)
+
+	fileHeader, err := c.FormFile("file")
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"error": fmt.Sprintf("file exceeds %d bytes", maxBytes),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file required"})
+		return
+	}
+
+	if fileHeader.Size > maxBytes {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"error": fmt.Sprintf("file exceeds %d bytes", maxBytes),
+		})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "cant read file"})
+		return
+	}
+	defer file.Close()
+
+	// sniff the content instead of trusting the client supplied header
+	reader := bufio.NewReaderSize(file, 512)
+	head, _ := reader.Peek(512)
+	mimeType := http.DetectContentType(head)
+	if !allowedAttachmentTypes[mimeType] {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{
+			"error": "unsupported file type: " + mimeType,
+		})
+		return
+	}
+
+	attachment := database.NoteAttachment{
+		ID:       uuid.New(),
+		NoteID:   note.ID,
+		UserID:   userID,
+		FileName: filepath.Base(fileHeader.Filename),
+		MimeType: mimeType,
+		Size:     fileHeader.Size,
+	}
+	attachment.StorageKey = "notes/" + note.ID.String() + "/" + attachment.ID.String()
+
+	var content io.Reader = reader
+	if database.NotesEncryptionEnabled() {
+		plain, err := io.ReadAll(reader)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "cant read file"})
+			return
+		}
+
+		sealed, err := database.SealForUser(userID, plain)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "cant encrypt file"})
+			return
+		}
+
+		content = bytes.NewReader(sealed)
+		attachment.Encrypted = true
+	}
+
+	if err := storage.Store.Put(attachment.StorageKey, content); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "cant store file"})
+		return
+	}
+
+	if err := database.InsertAttachment(&attachment); err != nil {
+		storage.Store.Delete(attachment.StorageKey)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, attachment)
+}
+
+func DownloadAttachment(c *gin.Context) {
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "not authenticated"})
+		return
+	}
+	userID := userIDAny.(uuid.UUID)
+
+	id := c.Query("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id required"})
+		return
+	}
+
+	attachment, err := database.GetAttachment(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "attachment not found"})
+		return
+	}
+
+	// anyone who can read the note can read its attachments
+	if _, _, err := database.GetNoteAccess(attachment.NoteID.String(), userID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "attachment not found"})
+		return
+	}
+
+	blob, err := storage.Store.Get(attachment.StorageKey)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "attachment content missing"})
+		return
+	}
+	defer blob.Close()
+
+	var content io.Reader = blob
+	if attachment.Encrypted {
+		sealed, err := io.ReadAll(blob)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "cant read attachment"})
+			return
+		}
+
+		plain, err := database.OpenForUser(attachment.UserID, sealed)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "cant decrypt attachment"})
+			return
+		}
+		content = bytes.NewReader(plain)
+	}
+
+	c.DataFromReader(http.StatusOK, attachment.Size, attachment.MimeType, content, map[string]string{
+		"Content-Disposition": fmt.Sprintf("attachment; filename=%q", attachment.FileName),
+	})
+}
+
+func DeleteAttachment(c *gin.Context) {
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "not authenticated"})
+		return
+	}
+	userID := userIDAny.(uuid.UUID)
+
+	id := c.Query("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id required"})
+		return
+	}
+
+	attachment, err := database.GetAttachment(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "attachment not found"})
+		return
+	}
+
+	// the uploader or the note's owner, collaborators can't remove each other's files
+	if attachment.UserID != userID {
+		note, _, err := database.GetNoteAccess(attachment.NoteID.String(), userID)
+		if err != nil || note.UserID != userID {
+			c.JSON(http.StatusNotFound, gin.H{"error": "attachment not found"})
+			return
+		}
+	}
+
+	if err := storage.Store.Delete(attachment.StorageKey); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "cant delete file"})
+		return
+	}
+
+	if err := database.DeleteAttachment(attachment.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "deleted"})
+}
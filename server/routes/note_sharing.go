@@ -0,0 +1,117 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"yt_dashboard.com/database"
+)
+
+// either NoteID or VideoID, the latter shares every note of that video
+type ShareNoteRequest struct {
+	NoteID  string `json:"noteId"`
+	VideoID string `json:"videoId"`
+	Email   string `json:"email"`
+	Access  string `json:"access"`
+}
+
+func ShareNotes(c *gin.Context) {
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "not authenticated"})
+		return
+	}
+	userID := userIDAny.(uuid.UUID)
+
+	var body ShareNoteRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	if (body.NoteID == "") == (body.VideoID == "") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "exactly one of noteId or videoId required"})
+		return
+	}
+
+	if body.Access != database.ShareRead && body.Access != database.ShareWrite {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "access must be read or write"})
+		return
+	}
+
+	collaborator, err := database.GetUserByEmail(body.Email)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no dashboard user with that email"})
+		return
+	}
+
+	if collaborator.ID == userID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "cant share with yourself"})
+		return
+	}
+
+	share := database.NoteShare{
+		OwnerID:      userID,
+		SharedWithID: collaborator.ID,
+		VideoID:      body.VideoID,
+		Access:       body.Access,
+	}
+
+	if body.NoteID != "" {
+		note, err := database.GetNoteForUser(body.NoteID, userID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "note not found"})
+			return
+		}
+		share.NoteID = &note.ID
+		share.VideoID = note.VideoID
+	}
+
+	if err := database.InsertNoteShare(&share); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, share)
+}
+
+func GetNoteShares(c *gin.Context) {
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "not authenticated"})
+		return
+	}
+	userID := userIDAny.(uuid.UUID)
+
+	shares, err := database.GetNoteSharesByOwner(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": shares})
+}
+
+func RevokeNoteShare(c *gin.Context) {
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "not authenticated"})
+		return
+	}
+	userID := userIDAny.(uuid.UUID)
+
+	id := c.Query("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id required"})
+		return
+	}
+
+	if err := database.DeleteNoteShare(id, userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "revoked"})
+}
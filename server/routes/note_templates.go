@@ -0,0 +1,222 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"yt_dashboard.com/database"
+)
+
+type NoteTemplateRequest struct {
+	Name    string   `json:"name"`
+	Content string   `json:"content"`
+	Tags    []string `json:"tags"`
+}
+
+// read only view of videos.list snippet, VideoSnippet is what we write back
+type VideoSnippetDetails struct {
+	Title        string               `json:"title"`
+	Description  string               `json:"description"`
+	PublishedAt  string               `json:"publishedAt"`
+	ChannelTitle string               `json:"channelTitle"`
+	Tags         []string             `json:"tags"`
+	Thumbnails   map[string]Thumbnail `json:"thumbnails"`
+}
+
+func CreateNoteTemplate(c *gin.Context) {
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "not authenticated"})
+		return
+	}
+	userID := userIDAny.(uuid.UUID)
+
+	var body NoteTemplateRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	if body.Name == "" || body.Content == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name and content required"})
+		return
+	}
+
+	template := database.NoteTemplate{
+		UserID:  userID,
+		Name:    body.Name,
+		Content: body.Content,
+		Tags:    body.Tags,
+	}
+
+	if err := database.InsertNoteTemplate(&template); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, template)
+}
+
+func GetNoteTemplates(c *gin.Context) {
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "not authenticated"})
+		return
+	}
+	userID := userIDAny.(uuid.UUID)
+
+	templates, err := database.GetNoteTemplates(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"items":        templates,
+		"placeholders": noteTemplatePlaceholders,
+	})
+}
+
+func UpdateNoteTemplate(c *gin.Context) {
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "not authenticated"})
+		return
+	}
+	userID := userIDAny.(uuid.UUID)
+
+	id := c.Query("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id required"})
+		return
+	}
+
+	var body NoteTemplateRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	if body.Name == "" || body.Content == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name and content required"})
+		return
+	}
+
+	template, err := database.GetNoteTemplate(id, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "template not found"})
+		return
+	}
+
+	template.Name = body.Name
+	template.Content = body.Content
+	template.Tags = body.Tags
+
+	if err := database.UpdateNoteTemplate(&template); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, template)
+}
+
+func DeleteNoteTemplate(c *gin.Context) {
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "not authenticated"})
+		return
+	}
+	userID := userIDAny.(uuid.UUID)
+
+	id := c.Query("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id required"})
+		return
+	}
+
+	if err := database.DeleteNoteTemplate(id, userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "deleted"})
+}
+
+var noteTemplatePlaceholders = []string{
+	"videoId",
+	"videoTitle",
+	"videoDescription",
+	"publishDate",
+	"channelTitle",
+	"videoTags",
+	"thumbnail",
+	"today",
+}
+
+// noteTemplateVars resolves the placeholders above for a video
+func noteTemplateVars(videoId, token string) (map[string]string, error) {
+	snippet, err := getVideoSnippetDetails(videoId, token)
+	if err != nil {
+		return nil, err
+	}
+
+	publishDate := snippet.PublishedAt
+	if t, err := time.Parse(time.RFC3339, snippet.PublishedAt); err == nil {
+		publishDate = t.Format("2006-01-02")
+	}
+
+	return map[string]string{
+		"videoId":          videoId,
+		"videoTitle":       snippet.Title,
+		"videoDescription": snippet.Description,
+		"publishDate":      publishDate,
+		"channelTitle":     snippet.ChannelTitle,
+		"videoTags":        strings.Join(snippet.Tags, ", "),
+		"thumbnail":        getThumbnail(snippet.Thumbnails),
+		"today":            time.Now().Format("2006-01-02"),
+	}, nil
+}
+
+func getVideoSnippetDetails(videoId, token string) (VideoSnippetDetails, error) {
+	reqURL, _ := url.Parse("https://www.googleapis.com/youtube/v3/videos")
+	q := reqURL.Query()
+	q.Set("part", "snippet")
+	q.Set("id", videoId)
+	reqURL.RawQuery = q.Encode()
+
+	req, _ := http.NewRequest(http.MethodGet, reqURL.String(), nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return VideoSnippetDetails{}, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return VideoSnippetDetails{}, fmt.Errorf("videos.list failed")
+	}
+
+	var resp struct {
+		Items []struct {
+			Snippet VideoSnippetDetails `json:"snippet"`
+		} `json:"items"`
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(&resp); err != nil {
+		return VideoSnippetDetails{}, err
+	}
+
+	if len(resp.Items) == 0 {
+		return VideoSnippetDetails{}, fmt.Errorf("video not found")
+	}
+
+	return resp.Items[0].Snippet, nil
+}
@@ -3,18 +3,22 @@ package routes
 import (
 	"fmt"
 	"net/http"
+	"slices"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 
 	"yt_dashboard.com/database"
+	"yt_dashboard.com/storage"
+	"yt_dashboard.com/utils"
 )
 
 type CreateNoteRequest struct {
-	VideoID string   `json:"videoId"`
-	Content string   `json:"content"`
-	Tags    []string `json:"tags"`
+	VideoID    string   `json:"videoId"`
+	Content    string   `json:"content"`
+	Tags       []string `json:"tags"`
+	TemplateID string   `json:"templateId"`
 }
 
 func CreateNote(c *gin.Context) {
@@ -31,11 +35,34 @@ func CreateNote(c *gin.Context) {
 		return
 	}
 
-	if body.VideoID == "" || body.Content == "" {
+	if body.VideoID == "" || (body.Content == "" && body.TemplateID == "") {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "videoId and content required"})
 		return
 	}
 
+	// a template replaces the content, its tags are merged with the given ones
+	if body.TemplateID != "" {
+		template, err := database.GetNoteTemplate(body.TemplateID, userID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "template not found"})
+			return
+		}
+
+		token := c.MustGet("accessToken").(string)
+		vars, err := noteTemplateVars(body.VideoID, token)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		body.Content = utils.FillPlaceholders(template.Content, vars)
+		for _, tag := range template.Tags {
+			if !slices.Contains(body.Tags, tag) {
+				body.Tags = append(body.Tags, tag)
+			}
+		}
+	}
+
 	fmt.Printf("CAnt even fetch\n\n\n\n\n")
 
 	note := database.Note{
@@ -43,6 +70,8 @@ func CreateNote(c *gin.Context) {
 		VideoID: body.VideoID,
 		Content: body.Content,
 		Tags:    body.Tags,
+
+		LastEditedBy: userID,
 	}
 
 	if err := database.InsertNote(&note); err != nil {
@@ -54,11 +83,12 @@ func CreateNote(c *gin.Context) {
 }
 
 func GetNotes(c *gin.Context) {
-	_, exists := c.Get("userID")
+	userIDAny, exists := c.Get("userID")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "not authenticated"})
 		return
 	}
+	userID := userIDAny.(uuid.UUID)
 
 	videoId := c.Query("videoId")
 	if videoId == "" {
@@ -87,9 +117,9 @@ func GetNotes(c *gin.Context) {
 	)
 
 	if len(tags) > 0 {
-		notes, err = database.GetNotesByTags(videoId, limit, tags, cursor)
+		notes, err = database.GetNotesByTags(userID, videoId, limit, tags, cursor)
 	} else {
-		notes, err = database.GetNotes(videoId, limit, cursor)
+		notes, err = database.GetNotes(userID, videoId, limit, cursor)
 	}
 
 	if err != nil {
@@ -109,6 +139,53 @@ func GetNotes(c *gin.Context) {
 	})
 }
 
+type UpdateNoteRequest struct {
+	ID      string   `json:"id"`
+	Content string   `json:"content"`
+	Tags    []string `json:"tags"`
+}
+
+func UpdateNote(c *gin.Context) {
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "not authenticated"})
+		return
+	}
+	userID := userIDAny.(uuid.UUID)
+
+	var body UpdateNoteRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	if body.ID == "" || body.Content == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id and content required"})
+		return
+	}
+
+	note, access, err := database.GetNoteAccess(body.ID, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "note not found"})
+		return
+	}
+
+	if access != database.ShareWrite {
+		c.JSON(http.StatusForbidden, gin.H{"error": "read only access"})
+		return
+	}
+
+	note.Content = body.Content
+	note.Tags = body.Tags
+
+	if err := database.UpdateNote(&note, userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, note)
+}
+
 func DeleteNote(c *gin.Context) {
 	userIDAny, exists := c.Get("userID")
 	if !exists {
@@ -123,6 +200,14 @@ func DeleteNote(c *gin.Context) {
 		return
 	}
 
+	// attachment rows cascade with the note, their blobs do not
+	if note, err := database.GetNoteForUser(id, userID); err == nil {
+		attachments, _ := database.GetAttachmentsByNote(note.ID)
+		for _, attachment := range attachments {
+			storage.Store.Delete(attachment.StorageKey)
+		}
+	}
+
 	database.DB.
 		Where("id = ? AND user_id = ?", id, userID).
 		Delete(&database.Note{})
@@ -0,0 +1,114 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"yt_dashboard.com/database"
+)
+
+type NoteWithVideo struct {
+	database.Note
+	VideoTitle     string `json:"videoTitle"`
+	VideoThumbnail string `json:"videoThumbnail"`
+}
+
+type FacetCountWithTitle struct {
+	database.FacetCount
+	Title string `json:"title,omitempty"`
+}
+
+// GetAllNotes lists notes across every video with facet counts
+func GetAllNotes(c *gin.Context) {
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "not authenticated"})
+		return
+	}
+	userID := userIDAny.(uuid.UUID)
+
+	filter := database.NoteFilter{
+		VideoIDs: c.QueryArray("videoId"),
+		Tags:     c.QueryArray("tag"),
+	}
+
+	if m := c.Query("month"); m != "" {
+		t, err := time.Parse("2006-01", m)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "month must be YYYY-MM"})
+			return
+		}
+		filter.Month = &t
+	}
+
+	limit := 20
+	if l := c.Query("limit"); l != "" {
+		fmt.Sscan(l, &limit)
+	}
+
+	var cursor *time.Time
+	if cur := c.Query("cursor"); cur != "" {
+		t, err := time.Parse(time.RFC3339, cur)
+		if err == nil {
+			cursor = &t
+		}
+	}
+
+	notes, err := database.GetAllNotes(userID, filter, limit, cursor)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	facets, err := database.GetNoteFacets(userID, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// titles are a nice to have, notes are still listed if YouTube fails
+	uploads := map[string]VideoSummary{}
+	if token, ok := c.Get("accessToken"); ok {
+		if u, err := getCachedChannelUploads(userID.String(), token.(string)); err == nil {
+			uploads = u
+		}
+	}
+
+	items := make([]NoteWithVideo, 0, len(notes))
+	for _, note := range notes {
+		video := uploads[note.VideoID]
+		items = append(items, NoteWithVideo{
+			Note:           note,
+			VideoTitle:     video.Title,
+			VideoThumbnail: video.Thumbnail,
+		})
+	}
+
+	videoFacets := make([]FacetCountWithTitle, 0, len(facets.Videos))
+	for _, f := range facets.Videos {
+		videoFacets = append(videoFacets, FacetCountWithTitle{
+			FacetCount: f,
+			Title:      uploads[f.Value].Title,
+		})
+	}
+
+	var nextCursor *time.Time
+	if len(notes) > 0 {
+		t := notes[len(notes)-1].CreatedAt
+		nextCursor = &t
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"items": items,
+		"facets": gin.H{
+			"videos": videoFacets,
+			"tags":   facets.Tags,
+			"months": facets.Months,
+		},
+		"nextCursor": nextCursor,
+	})
+}
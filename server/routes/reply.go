@@ -5,13 +5,22 @@ import (
 	"encoding/json"
 	"io"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"yt_dashboard.com/database"
 )
 
 type ReplyRequest struct {
 	ParentID string `json:"parentId"`
 	Text     string `json:"text"`
+	// post a reply template instead of Text, Variables override resolved values
+	TemplateID string            `json:"templateId"`
+	Variables  map[string]string `json:"variables"`
+	// future times queue the reply, the template is rendered right away
+	ScheduledAt *time.Time `json:"scheduledAt"`
 }
 
 func ReplyToComment(c *gin.Context) {
@@ -28,10 +37,42 @@ func ReplyToComment(c *gin.Context) {
 		return
 	}
 
+	if body.TemplateID != "" {
+		text, err := renderReplyTemplate(body.TemplateID, c.MustGet("userID").(uuid.UUID), body.ParentID, token, body.Variables)
+		if err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+		body.Text = text
+	}
+
+	if body.ScheduledAt != nil && body.ScheduledAt.After(time.Now()) {
+		scheduleCommentJob(c, database.CommentJob{
+			Kind:     database.JobReply,
+			ParentID: body.ParentID,
+			Text:     body.Text,
+		}, *body.ScheduledAt)
+		return
+	}
+
+	if err := postReply(body.ParentID, body.Text, token); err != nil {
+		respondWriteError(c, err)
+		return
+	}
+
+	// replying is what moves a comment out of the moderators' queue
+	if userID, ok := c.Get("userID"); ok {
+		database.SetTriageStatus(body.ParentID, database.TriageAnswered, userID.(uuid.UUID))
+	}
+
+	c.JSON(200, gin.H{"status": "reply added", "text": body.Text})
+}
+
+func postReply(parentId string, text string, token string) error {
 	payload := map[string]any{
 		"snippet": map[string]any{
-			"parentId":     body.ParentID,
-			"textOriginal": body.Text,
+			"parentId":     parentId,
+			"textOriginal": text,
 		},
 	}
 
@@ -47,16 +88,14 @@ func ReplyToComment(c *gin.Context) {
 
 	res, err := http.DefaultClient.Do(req)
 	if err != nil {
-		c.JSON(500, gin.H{"error": err.Error()})
-		return
+		return err
 	}
 	defer res.Body.Close()
 
 	if res.StatusCode != http.StatusOK {
 		b, _ := io.ReadAll(res.Body)
-		c.JSON(500, gin.H{"error": string(b)})
-		return
+		return &YTError{Status: res.StatusCode, Body: string(b)}
 	}
 
-	c.JSON(200, gin.H{"status": "reply added"})
+	return nil
 }
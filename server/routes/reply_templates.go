@@ -0,0 +1,229 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"yt_dashboard.com/database"
+	"yt_dashboard.com/utils"
+)
+
+type ReplyTemplateRequest struct {
+	Name   string `json:"name"`
+	Text   string `json:"text"`
+	Shared bool   `json:"shared"`
+}
+
+var replyTemplateVariables = []string{
+	"author",
+	"commentText",
+	"videoId",
+	"videoTitle",
+	"channelName",
+}
+
+func CreateReplyTemplate(c *gin.Context) {
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "not authenticated"})
+		return
+	}
+	userID := userIDAny.(uuid.UUID)
+
+	var body ReplyTemplateRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	if body.Name == "" || body.Text == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name and text required"})
+		return
+	}
+
+	template := database.ReplyTemplate{
+		UserID: userID,
+		Name:   body.Name,
+		Text:   body.Text,
+		Shared: body.Shared,
+	}
+
+	if err := database.InsertReplyTemplate(&template); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, template)
+}
+
+func GetReplyTemplates(c *gin.Context) {
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "not authenticated"})
+		return
+	}
+	userID := userIDAny.(uuid.UUID)
+
+	templates, err := database.GetReplyTemplates(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"items":     templates,
+		"variables": replyTemplateVariables,
+	})
+}
+
+func UpdateReplyTemplate(c *gin.Context) {
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "not authenticated"})
+		return
+	}
+	userID := userIDAny.(uuid.UUID)
+
+	id := c.Query("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id required"})
+		return
+	}
+
+	var body ReplyTemplateRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	if body.Name == "" || body.Text == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name and text required"})
+		return
+	}
+
+	// shared templates can be used by the team but only changed by their author
+	template, err := database.GetOwnReplyTemplate(id, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "template not found"})
+		return
+	}
+
+	template.Name = body.Name
+	template.Text = body.Text
+	template.Shared = body.Shared
+
+	if err := database.UpdateReplyTemplate(&template); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, template)
+}
+
+func DeleteReplyTemplate(c *gin.Context) {
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "not authenticated"})
+		return
+	}
+	userID := userIDAny.(uuid.UUID)
+
+	id := c.Query("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id required"})
+		return
+	}
+
+	if err := database.DeleteReplyTemplate(id, userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "deleted"})
+}
+
+// renderReplyTemplate fills a template from the parent comment and its video
+func renderReplyTemplate(templateId string, userID uuid.UUID, parentId string, token string, overrides map[string]string) (string, error) {
+	template, err := database.GetReplyTemplate(templateId, userID)
+	if err != nil {
+		return "", fmt.Errorf("template not found")
+	}
+
+	return fillReplyTemplate(template, parentId, token, overrides, nil)
+}
+
+// fillReplyTemplate is renderReplyTemplate for an already loaded template,
+// videos may be nil
+func fillReplyTemplate(template database.ReplyTemplate, parentId string, token string, overrides map[string]string, videos *videoSnippetCache) (string, error) {
+	vars, err := replyTemplateVars(parentId, token, videos)
+	if err != nil {
+		return "", err
+	}
+
+	for k, v := range overrides {
+		vars[k] = v
+	}
+
+	return utils.FillPlaceholders(template.Text, vars), nil
+}
+
+func replyTemplateVars(parentId string, token string, videos *videoSnippetCache) (map[string]string, error) {
+	parent, err := fetchCommentById(parentId, token)
+	if err != nil {
+		return nil, err
+	}
+
+	vars := map[string]string{
+		"author":      parent.Snippet.AuthorDisplayName,
+		"commentText": parent.Snippet.TextOriginal,
+		"videoId":     parent.Snippet.VideoId,
+	}
+
+	if parent.Snippet.VideoId != "" {
+		video, err := videos.get(parent.Snippet.VideoId, token)
+		if err != nil {
+			return nil, err
+		}
+		vars["videoTitle"] = video.Title
+		vars["channelName"] = video.ChannelTitle
+	}
+
+	return vars, nil
+}
+
+// videoSnippetCache keeps video snippets for the length of one bulk request,
+// replies to comments of the same video share its title and channel
+type videoSnippetCache struct {
+	mu     sync.Mutex
+	videos map[string]VideoSnippetDetails
+}
+
+func newVideoSnippetCache() *videoSnippetCache {
+	return &videoSnippetCache{videos: map[string]VideoSnippetDetails{}}
+}
+
+// get fetches straight from YouTube on a nil cache, the lock is held while
+// fetching so concurrent items of one video wait for a single lookup
+func (c *videoSnippetCache) get(videoId string, token string) (VideoSnippetDetails, error) {
+	if c == nil {
+		return getVideoSnippetDetails(videoId, token)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if video, ok := c.videos[videoId]; ok {
+		return video, nil
+	}
+
+	video, err := getVideoSnippetDetails(videoId, token)
+	if err != nil {
+		return video, err
+	}
+	c.videos[videoId] = video
+	return video, nil
+}
@@ -0,0 +1,252 @@
+package routes
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+
+	"yt_dashboard.com/database"
+	"yt_dashboard.com/utils"
+)
+
+const (
+	defaultSpamThreshold = 0.6
+	// shorter texts ("first!", "nice video") repeat innocently
+	minDuplicateLength = 20
+	// name similarity above which an author looks like an impersonator
+	impersonationSimilarity = 0.8
+	// how far back other videos' comments are compared for copy-pasted text
+	duplicateWindow = 7 * 24 * time.Hour
+	maxSpamCorpus   = 20000
+)
+
+var (
+	linkRe = regexp.MustCompile(`(?i)(https?://|www\.)\S+|\b[a-z0-9-]+\.(com|net|org|io|xyz|ru|me|ly|gg|co|info|biz|top|link|site|online|live)\b(/\S*)?`)
+	scamRe = regexp.MustCompile(`(?i)\b(crypto|bitcoin|btc|eth|usdt|forex|binary options|invest(ment|ing)?|telegram|whats ?app|wa\.me|t\.me|dm me|text me|contact me)\b`)
+)
+
+var (
+	channelTitles   = map[string]string{}
+	channelTitlesMu sync.RWMutex
+)
+
+type SpamScore struct {
+	Score      float64  `json:"score"`
+	LikelySpam bool     `json:"likelySpam"`
+	Reasons    []string `json:"reasons,omitempty"`
+}
+
+type spamOptions struct {
+	threshold float64
+	filter    string // only, hide or empty
+}
+
+// spamScorer holds what the heuristics need beyond the comment itself
+type spamScorer struct {
+	channelId    string
+	channelTitle string
+	// normalized text to the ids of the comments that posted it
+	texts     map[string]map[string]bool
+	threshold float64
+	// allowlisted authors are scored but never flagged
+	allowed map[string]bool
+}
+
+func parseSpamOptions(c *gin.Context) (spamOptions, error) {
+	opts := spamOptions{
+		threshold: defaultSpamThreshold,
+		filter:    c.Query("spam"),
+	}
+
+	if v := os.Getenv("SPAM_THRESHOLD"); v != "" {
+		if t, err := strconv.ParseFloat(v, 64); err == nil {
+			opts.threshold = t
+		}
+	}
+
+	if v := c.Query("spamThreshold"); v != "" {
+		t, err := strconv.ParseFloat(v, 64)
+		if err != nil || t < 0 || t > 1 {
+			return opts, fmt.Errorf("spamThreshold must be between 0 and 1")
+		}
+		opts.threshold = t
+	}
+
+	if opts.filter != "" && opts.filter != "only" && opts.filter != "hide" {
+		return opts, fmt.Errorf("spam must be only or hide")
+	}
+
+	return opts, nil
+}
+
+/*
+applySpamScores
+- Scores every comment and reply of the page, see score for the heuristics
+- Duplicates are counted over the page, the video's stored comments and the channel's recent ones
+- spam=only keeps threads with a likely spam comment, spam=hide drops threads whose top comment is likely spam
+*/
+func applySpamScores(items []CommentThreadItem, videoId string, token string, opts spamOptions) ([]CommentThreadItem, error) {
+	if len(items) == 0 {
+		return items, nil
+	}
+
+	scorer := spamScorer{
+		channelId: items[0].Snippet.ChannelId,
+		texts:     map[string]map[string]bool{},
+		threshold: opts.threshold,
+		allowed:   map[string]bool{},
+	}
+
+	for _, item := range items {
+		if item.AuthorList == database.AuthorAllow {
+			scorer.allowed[item.Snippet.TopLevelComment.Snippet.AuthorChannelId.Value] = true
+		}
+		for _, reply := range item.Replies.Comments {
+			if reply.AuthorList == database.AuthorAllow {
+				scorer.allowed[reply.Snippet.AuthorChannelId.Value] = true
+			}
+		}
+	}
+
+	stored, err := database.GetSpamCorpus(scorer.channelId, videoId, time.Now().Add(-duplicateWindow), maxSpamCorpus)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range stored {
+		scorer.addText(row.ID, row.TextOriginal)
+	}
+
+	for _, item := range items {
+		scorer.addText(item.Snippet.TopLevelComment.Id, item.Snippet.TopLevelComment.Snippet.TextOriginal)
+		for _, reply := range item.Replies.Comments {
+			scorer.addText(reply.Id, reply.Snippet.TextOriginal)
+		}
+	}
+
+	// without a title the impersonation check is skipped
+	scorer.channelTitle, _ = channelTitle(scorer.channelId, videoId, token)
+
+	filtered := make([]CommentThreadItem, 0, len(items))
+	for _, item := range items {
+		item.Spam = scorer.score(item.Snippet.TopLevelComment.Snippet)
+		anySpam := item.Spam.LikelySpam
+
+		for j := range item.Replies.Comments {
+			item.Replies.Comments[j].Spam = scorer.score(item.Replies.Comments[j].Snippet)
+			anySpam = anySpam || item.Replies.Comments[j].Spam.LikelySpam
+		}
+
+		if opts.filter == "only" && !anySpam {
+			continue
+		}
+		if opts.filter == "hide" && item.Spam.LikelySpam {
+			continue
+		}
+		filtered = append(filtered, item)
+	}
+
+	return filtered, nil
+}
+
+func (s *spamScorer) addText(id string, text string) {
+	norm := utils.NormalizeText(text)
+	if utf8.RuneCountInString(norm) < minDuplicateLength {
+		return
+	}
+	if s.texts[norm] == nil {
+		s.texts[norm] = map[string]bool{}
+	}
+	s.texts[norm][id] = true
+}
+
+/*
+score
+- Every signal is a probability-like weight, they are combined as 1 - product(1 - w)
+- Links, scam words, copy-pasted text, look-alike channel names and emoji floods
+*/
+func (s *spamScorer) score(snippet CommentSnippet) *SpamScore {
+	text := snippet.TextOriginal
+	notSpam := 1.0
+	reasons := []string{}
+
+	add := func(weight float64, reason string) {
+		notSpam *= 1 - weight
+		reasons = append(reasons, reason)
+	}
+
+	links := len(linkRe.FindAllStringIndex(text, -1))
+	switch {
+	case links >= 2:
+		add(0.6, fmt.Sprintf("contains %d links", links))
+	case links == 1:
+		add(0.35, "contains a link")
+	}
+
+	if scamRe.MatchString(text) {
+		add(0.3, "mentions crypto, investing or off-platform contact")
+	}
+
+	norm := utils.NormalizeText(text)
+	if copies := len(s.texts[norm]); copies >= 2 {
+		weight := 0.4
+		if copies >= 4 {
+			weight = 0.7
+		}
+		add(weight, fmt.Sprintf("same text posted %d times", copies))
+	}
+
+	if s.channelTitle != "" && snippet.AuthorChannelId.Value != s.channelId {
+		author := utils.NormalizeText(snippet.AuthorDisplayName)
+		if utils.Similarity(author, utils.NormalizeText(s.channelTitle)) >= impersonationSimilarity {
+			add(0.8, "author name resembles the channel name")
+		}
+	}
+
+	emoji, total := utils.CountEmoji(text)
+	if emoji >= 10 || (emoji >= 5 && emoji*2 > total) {
+		add(0.3, fmt.Sprintf("%d emoji", emoji))
+	}
+
+	score := math.Round((1-notSpam)*100) / 100
+	likelySpam := score >= s.threshold
+
+	if s.allowed[snippet.AuthorChannelId.Value] {
+		likelySpam = false
+		reasons = append(reasons, "author is on the allowlist")
+	}
+
+	return &SpamScore{
+		Score:      score,
+		LikelySpam: likelySpam,
+		Reasons:    reasons,
+	}
+}
+
+// channelTitle is cached per channel, titles rarely change and every
+// lookup costs quota
+func channelTitle(channelId string, videoId string, token string) (string, error) {
+	channelTitlesMu.RLock()
+	title, ok := channelTitles[channelId]
+	channelTitlesMu.RUnlock()
+	if ok {
+		return title, nil
+	}
+
+	video, err := getVideoSnippetDetails(videoId, token)
+	if err != nil {
+		return "", err
+	}
+
+	channelTitlesMu.Lock()
+	channelTitles[channelId] = video.ChannelTitle
+	channelTitlesMu.Unlock()
+
+	return video.ChannelTitle, nil
+}
@@ -0,0 +1,138 @@
+package routes
+
+import (
+	"slices"
+	"testing"
+)
+
+func testSnippet(authorId string, authorName string, text string) CommentSnippet {
+	s := CommentSnippet{AuthorDisplayName: authorName, TextOriginal: text}
+	s.AuthorChannelId.Value = authorId
+	return s
+}
+
+func TestSpamScore(t *testing.T) {
+	const duplicate = "Check out my channel for the best tutorials"
+	const channelId = "UCchannel"
+
+	tests := []struct {
+		name       string
+		snippet    CommentSnippet
+		copies     int
+		allowed    bool
+		score      float64
+		likelySpam bool
+		reason     string
+	}{
+		{
+			name:    "clean comment",
+			snippet: testSnippet("UCviewer", "Viewer", "Great explanation, thanks for the video"),
+			score:   0,
+		},
+		{
+			name:    "one link",
+			snippet: testSnippet("UCviewer", "Viewer", "more at https://example.com/page"),
+			score:   0.35,
+			reason:  "contains a link",
+		},
+		{
+			name:       "two links",
+			snippet:    testSnippet("UCviewer", "Viewer", "see www.example.com and spam.xyz"),
+			score:      0.6,
+			likelySpam: true,
+			reason:     "contains 2 links",
+		},
+		{
+			name:    "scam words",
+			snippet: testSnippet("UCviewer", "Viewer", "I made a fortune with bitcoin"),
+			score:   0.3,
+			reason:  "mentions crypto, investing or off-platform contact",
+		},
+		{
+			name:       "links and scam words combine",
+			snippet:    testSnippet("UCviewer", "Viewer", "telegram t.me/invest and www.example.com"),
+			score:      0.72,
+			likelySpam: true,
+		},
+		{
+			name:    "text posted twice",
+			snippet: testSnippet("UCviewer", "Viewer", duplicate),
+			copies:  2,
+			score:   0.4,
+			reason:  "same text posted 2 times",
+		},
+		{
+			name:       "text posted four times",
+			snippet:    testSnippet("UCviewer", "Viewer", duplicate),
+			copies:     4,
+			score:      0.7,
+			likelySpam: true,
+			reason:     "same text posted 4 times",
+		},
+		{
+			name:       "author impersonates the channel",
+			snippet:    testSnippet("UCimpostor", "My Channe1", "reply to claim your prize"),
+			score:      0.8,
+			likelySpam: true,
+			reason:     "author name resembles the channel name",
+		},
+		{
+			name:    "the channel itself",
+			snippet: testSnippet(channelId, "My Channel", "thanks for watching"),
+			score:   0,
+		},
+		{
+			name:    "emoji flood",
+			snippet: testSnippet("UCviewer", "Viewer", "🔥🔥🔥🔥🔥🔥🔥🔥🔥🔥"),
+			score:   0.3,
+			reason:  "10 emoji",
+		},
+		{
+			name:    "allowlisted author is never flagged",
+			snippet: testSnippet("UCfriend", "Friend", "see www.example.com and spam.xyz"),
+			allowed: true,
+			score:   0.6,
+			reason:  "author is on the allowlist",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := spamScorer{
+				channelId:    channelId,
+				channelTitle: "My Channel",
+				texts:        map[string]map[string]bool{},
+				threshold:    defaultSpamThreshold,
+				allowed:      map[string]bool{},
+			}
+			for i := range tt.copies {
+				s.addText(string(rune('a'+i)), tt.snippet.TextOriginal)
+			}
+			if tt.allowed {
+				s.allowed[tt.snippet.AuthorChannelId.Value] = true
+			}
+
+			got := s.score(tt.snippet)
+			if got.Score != tt.score {
+				t.Errorf("score = %v, want %v (reasons %v)", got.Score, tt.score, got.Reasons)
+			}
+			if got.LikelySpam != tt.likelySpam {
+				t.Errorf("likelySpam = %v, want %v", got.LikelySpam, tt.likelySpam)
+			}
+			if tt.reason != "" && !slices.Contains(got.Reasons, tt.reason) {
+				t.Errorf("reasons = %v, want %q", got.Reasons, tt.reason)
+			}
+		})
+	}
+}
+
+func TestSpamScoreShortTextsAreNotDuplicates(t *testing.T) {
+	s := spamScorer{texts: map[string]map[string]bool{}, threshold: defaultSpamThreshold}
+	for _, id := range []string{"a", "b", "c", "d"} {
+		s.addText(id, "first!")
+	}
+
+	if got := s.score(testSnippet("UCviewer", "Viewer", "first!")); got.Score != 0 {
+		t.Errorf("score = %v, want 0 (reasons %v)", got.Score, got.Reasons)
+	}
+}
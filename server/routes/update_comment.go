@@ -0,0 +1,132 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"yt_dashboard.com/database"
+)
+
+type UpdateCommentRequest struct {
+	CommentID string `json:"commentId"`
+	Text      string `json:"text"`
+}
+
+// UpdateComment edits a comment or reply written by the channel itself
+func UpdateComment(c *gin.Context) {
+	tokenAny, exists := c.Get("accessToken")
+	if !exists {
+		c.JSON(401, gin.H{"error": "not authenticated"})
+		return
+	}
+	token := tokenAny.(string)
+
+	var body UpdateCommentRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(400, gin.H{"error": "invalid request"})
+		return
+	}
+
+	if body.CommentID == "" || body.Text == "" {
+		c.JSON(400, gin.H{"error": "commentId and text required"})
+		return
+	}
+
+	comment, err := fetchCommentById(body.CommentID, token)
+	if err != nil {
+		c.JSON(404, gin.H{"error": err.Error()})
+		return
+	}
+
+	channelId, err := getMyChannelID(token)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	// YouTube would reject it anyway, checking first gives a clear error
+	if comment.Snippet.AuthorChannelId.Value != channelId {
+		c.JSON(403, gin.H{"error": "only comments written by your channel can be edited"})
+		return
+	}
+
+	payload := map[string]any{
+		"id": body.CommentID,
+		"snippet": map[string]any{
+			"textOriginal": body.Text,
+		},
+	}
+
+	jsonBody, _ := json.Marshal(payload)
+
+	req, _ := http.NewRequest(
+		http.MethodPut,
+		"https://www.googleapis.com/youtube/v3/comments?part=snippet",
+		bytes.NewReader(jsonBody),
+	)
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(res.Body)
+		c.JSON(500, gin.H{"error": string(b)})
+		return
+	}
+
+	var updated ReplyComment
+	json.NewDecoder(res.Body).Decode(&updated)
+
+	// keep the local copy in step until the next sync
+	database.UpdateCommentText(body.CommentID, body.Text, time.Now())
+
+	c.JSON(200, gin.H{"status": "comment updated", "comment": updated})
+}
+
+func fetchCommentById(commentId string, token string) (*ReplyComment, error) {
+	reqURL, _ := url.Parse("https://www.googleapis.com/youtube/v3/comments")
+
+	q := reqURL.Query()
+	q.Set("part", "snippet")
+	q.Set("id", commentId)
+	q.Set("textFormat", "plainText")
+	reqURL.RawQuery = q.Encode()
+
+	req, _ := http.NewRequest(http.MethodGet, reqURL.String(), nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(res.Body)
+		return nil, fmt.Errorf("comments error: %s", body)
+	}
+
+	var ytRes YTCommentListResponse
+	if err := json.NewDecoder(res.Body).Decode(&ytRes); err != nil {
+		return nil, err
+	}
+
+	if len(ytRes.Items) == 0 {
+		return nil, fmt.Errorf("comment not found")
+	}
+
+	return &ytRes.Items[0], nil
+}
@@ -6,8 +6,15 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"sort"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"yt_dashboard.com/database"
+	"yt_dashboard.com/utils"
 )
 
 type CommentSnippet struct {
@@ -18,6 +25,13 @@ type CommentSnippet struct {
 		Value string `json:"value"`
 	} `json:"authorChannelId"`
 	TextOriginal string `json:"textOriginal"`
+	TextDisplay  string `json:"textDisplay"`
+	VideoId      string `json:"videoId"`
+	ParentId     string `json:"parentId,omitempty"`
+	CanRate      bool   `json:"canRate"`
+	LikeCount    int    `json:"likeCount"`
+	PublishedAt  string `json:"publishedAt"`
+	UpdatedAt    string `json:"updatedAt"`
 }
 
 type TopLevelComment struct {
@@ -26,24 +40,59 @@ type TopLevelComment struct {
 }
 
 type ReplyComment struct {
-	Id      string         `json:"id"`
-	Snippet CommentSnippet `json:"snippet"`
+	Id              string                    `json:"id"`
+	Snippet         CommentSnippet            `json:"snippet"`
+	PendingDeletion *database.PendingDeletion `json:"pendingDeletion,omitempty"`
+	Tags            []string                  `json:"tags,omitempty"`
+	Spam            *SpamScore                `json:"spam,omitempty"`
+	AuthorList      string                    `json:"authorList,omitempty"`
+	Sentiment       *utils.Sentiment          `json:"sentiment,omitempty"`
 }
 
 type CommentThreadItem struct {
 	Id      string `json:"id"`
 	Snippet struct {
 		ChannelId       string          `json:"channelId"`
+		VideoId         string          `json:"videoId"`
 		TopLevelComment TopLevelComment `json:"topLevelComment"`
+		CanReply        bool            `json:"canReply"`
+		TotalReplyCount int             `json:"totalReplyCount"`
+		IsPublic        bool            `json:"isPublic"`
 	} `json:"snippet"`
 	Replies struct {
 		Comments []ReplyComment `json:"comments"`
 	} `json:"replies"`
+	// commentThreads only embeds a few replies, the rest come from /comments/replies
+	RepliesTruncated bool `json:"repliesTruncated"`
+	// local moderation state, see comment_triage.go
+	Triage *database.CommentTriage `json:"triage,omitempty"`
+	// set on the top level comment's deletion, replies carry their own
+	PendingDeletion *database.PendingDeletion `json:"pendingDeletion,omitempty"`
+	// added by comment rules, see comment_rules.go
+	Tags []string `json:"tags,omitempty"`
+	// local spam heuristics of the top level comment, see spam_score.go
+	Spam *SpamScore `json:"spam,omitempty"`
+	// block or allow when the author is on one of the user's lists
+	AuthorList string `json:"authorList,omitempty"`
+	// lexicon based, see utils/sentiment.go
+	Sentiment *utils.Sentiment `json:"sentiment,omitempty"`
 }
 
 type YTCommentThreadResponse struct {
 	NextPageToken string              `json:"nextPageToken"`
 	Items         []CommentThreadItem `json:"items"`
+	// set when served from the local store, see comment_sync.go
+	Source   string     `json:"source,omitempty"`
+	SyncedAt *time.Time `json:"syncedAt,omitempty"`
+}
+
+// CommentListOptions are passed through to commentThreads.list
+type CommentListOptions struct {
+	Order       string // time or relevance
+	SearchTerms string
+	MaxResults  int // 1-100, 0 keeps YouTube's default of 20
+	// heldForReview or likelySpam, only the channel owner may ask for these
+	ModerationStatus string
 }
 
 func GetCommentThread(c *gin.Context) {
@@ -73,7 +122,74 @@ func GetCommentThread(c *gin.Context) {
 		return
 	}
 
-	ytres, err := fetchComments(videoId, pageToken, token)
+	opts := CommentListOptions{
+		Order:       c.Query("order"),
+		SearchTerms: c.Query("searchTerms"),
+	}
+
+	if opts.Order != "" && opts.Order != "time" && opts.Order != "relevance" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "order must be time or relevance",
+		})
+		return
+	}
+
+	if m := c.Query("maxResults"); m != "" {
+		n, err := strconv.Atoi(m)
+		if err != nil || n < 1 || n > 100 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "maxResults must be between 1 and 100",
+			})
+			return
+		}
+		opts.MaxResults = n
+	}
+
+	sortByLikes := c.Query("sort") == "likes"
+
+	filter, err := parseTriageFilter(c, c.MustGet("userID").(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	spamOpts, err := parseSpamOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	authorFilter, err := parseAuthorListFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	sentimentFilter, err := parseSentimentFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	// synced videos are served locally, relevance ordering only exists on YouTube
+	var ytres *YTCommentThreadResponse
+	if c.Query("source") != "live" && opts.Order != "relevance" {
+		ytres, err = getStoredComments(videoId, pageToken, opts, sortByLikes)
+	}
+	if ytres == nil {
+		ytres, err = fetchComments(videoId, pageToken, token, opts)
+		if ytres != nil {
+			ytres.Source = "live"
+		}
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Cant fetch comments",
@@ -81,17 +197,90 @@ func GetCommentThread(c *gin.Context) {
 		return
 	}
 
-	fmt.Printf("Comments : %s\n", ytres.Items[0].Snippet.TopLevelComment.Snippet.TextOriginal)
+	lists, err := database.GetAuthorLists(c.MustGet("userID").(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Cant load author lists",
+		})
+		return
+	}
+
+	markTruncatedReplies(ytres.Items)
+
+	// triage filters apply to the fetched page, pages may come back short
+	ytres.Items, err = applyTriage(ytres.Items, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Cant load triage state",
+		})
+		return
+	}
+
+	if err := markPendingDeletions(ytres.Items); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Cant load pending deletions",
+		})
+		return
+	}
+
+	if err := markTags(ytres.Items); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Cant load comment tags",
+		})
+		return
+	}
+
+	// stored comments of blocked authors were moderated on sync or when they
+	// were blocked, live pages only tag them, viewing never spends write quota
+	ytres.Items = applyAuthorLists(ytres.Items, lists, authorFilter)
+
+	ytres.Items, err = applySpamScores(ytres.Items, videoId, token, spamOpts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Cant score comments",
+		})
+		return
+	}
+
+	// like triage, the sentiment filter applies to the fetched page
+	ytres.Items = applySentiment(ytres.Items, sentimentFilter)
+
+	// YouTube has no like ordering, so this only sorts the fetched page
+	if sortByLikes && ytres.Source == "live" {
+		sortThreadsByLikes(ytres.Items)
+	}
 
 	c.JSON(200, ytres)
 }
 
-func fetchComments(videoId string, pageToken string, token string) (*YTCommentThreadResponse, error) {
+func fetchComments(videoId string, pageToken string, token string, opts CommentListOptions) (*YTCommentThreadResponse, error) {
+	return fetchCommentThreads("videoId", videoId, pageToken, token, opts)
+}
+
+// fetchChannelComments lists threads across every video of a channel
+func fetchChannelComments(channelId string, pageToken string, token string, opts CommentListOptions) (*YTCommentThreadResponse, error) {
+	return fetchCommentThreads("allThreadsRelatedToChannelId", channelId, pageToken, token, opts)
+}
+
+func fetchCommentThreads(filter string, value string, pageToken string, token string, opts CommentListOptions) (*YTCommentThreadResponse, error) {
 	reqURL, _ := url.Parse("https://www.googleapis.com/youtube/v3/commentThreads")
 
 	q := reqURL.Query()
 	q.Set("part", "snippet,replies")
-	q.Set("videoId", videoId)
+	q.Set(filter, value)
+
+	if opts.Order != "" {
+		q.Set("order", opts.Order)
+	}
+	if opts.SearchTerms != "" {
+		q.Set("searchTerms", opts.SearchTerms)
+	}
+	if opts.MaxResults > 0 {
+		q.Set("maxResults", strconv.Itoa(opts.MaxResults))
+	}
+	if opts.ModerationStatus != "" {
+		q.Set("moderationStatus", opts.ModerationStatus)
+	}
 
 	if pageToken != "" {
 		q.Set("pageToken", pageToken)
@@ -120,3 +309,15 @@ func fetchComments(videoId string, pageToken string, token string) (*YTCommentTh
 
 	return &ytRes, nil
 }
+
+func markTruncatedReplies(items []CommentThreadItem) {
+	for i := range items {
+		items[i].RepliesTruncated = len(items[i].Replies.Comments) < items[i].Snippet.TotalReplyCount
+	}
+}
+
+func sortThreadsByLikes(items []CommentThreadItem) {
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Snippet.TopLevelComment.Snippet.LikeCount > items[j].Snippet.TopLevelComment.Snippet.LikeCount
+	})
+}
@@ -0,0 +1,51 @@
+package routes
+
+import (
+	"errors"
+	"net"
+	"net/http"
+)
+
+// YTError is a YouTube API answer other than the expected success status
+type YTError struct {
+	Status int
+	Body   string
+}
+
+func (e *YTError) Error() string {
+	return e.Body
+}
+
+// what a failed YouTube write means for retrying it
+const (
+	writeRetryable = "retryable" // nothing was written, safe to try again
+	writeRejected  = "rejected"  // YouTube refused it, trying again won't help
+	writeUnknown   = "unknown"   // it may have gone through
+)
+
+/*
+classifyWriteError
+- 429 is retryable, other 4xx are rejections
+- 5xx and errors after the connection was made leave the outcome unknown
+- A failed dial never reached YouTube, so it is retryable
+*/
+func classifyWriteError(err error) string {
+	var ytErr *YTError
+	if errors.As(err, &ytErr) {
+		switch {
+		case ytErr.Status == http.StatusTooManyRequests:
+			return writeRetryable
+		case ytErr.Status < http.StatusInternalServerError:
+			return writeRejected
+		default:
+			return writeUnknown
+		}
+	}
+
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return writeRetryable
+	}
+
+	return writeUnknown
+}
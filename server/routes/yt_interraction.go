@@ -83,6 +83,7 @@ type Statistics struct {
 	ViewCount    string `json:"viewCount"`
 	LikeCount    string `json:"likeCount"`
 	DislikeCount string `json:"dislikeCount,omitempty"`
+	CommentCount string `json:"commentCount,omitempty"`
 }
 
 type Player struct {
@@ -0,0 +1,43 @@
+package storage
+
+import (
+	"errors"
+	"io"
+	"os"
+)
+
+var ErrBlobNotFound = errors.New("blob not found")
+
+// BlobStore abstracts where binary payloads (note attachments etc.) live.
+// Keys are slash separated paths like "notes/<noteId>/<attachmentId>".
+type BlobStore interface {
+	Put(key string, r io.Reader) error
+	Get(key string) (io.ReadCloser, error)
+	Delete(key string) error
+}
+
+var Store BlobStore
+
+/*
+StoreInit
+- BLOB_STORE selects the backend, only "local" for now
+- BLOB_LOCAL_DIR is the root directory for the local backend
+*/
+func StoreInit() error {
+	switch os.Getenv("BLOB_STORE") {
+	case "", "local":
+		dir := os.Getenv("BLOB_LOCAL_DIR")
+		if dir == "" {
+			dir = "./blobs"
+		}
+
+		store, err := NewLocalStore(dir)
+		if err != nil {
+			return err
+		}
+		Store = store
+		return nil
+	default:
+		return errors.New("unsupported BLOB_STORE backend")
+	}
+}
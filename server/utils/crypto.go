@@ -29,22 +29,11 @@ func Encrypt(plain string) (string, error) {
 		return "", err
 	}
 
-	block, err := aes.NewCipher(key)
-	if err != nil {
-		return "", err
-	}
-
-	gcm, err := cipher.NewGCM(block)
+	cipherText, err := EncryptBytes(key, []byte(plain))
 	if err != nil {
 		return "", err
 	}
 
-	nonce := make([]byte, gcm.NonceSize())
-	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
-		return "", err
-	}
-
-	cipherText := gcm.Seal(nonce, nonce, []byte(plain), nil)
 	return base64.StdEncoding.EncodeToString(cipherText), nil
 }
 
@@ -63,26 +52,71 @@ func Decrypt(cipherB64 string) (string, error) {
 		return "", err
 	}
 
-	block, err := aes.NewCipher(key)
+	plain, err := DecryptBytes(key, data)
 	if err != nil {
 		return "", err
 	}
 
+	return string(plain), nil
+}
+
+/*
+GenerateDataKey
+- Random 256 bit key for envelope encryption
+- Callers store it wrapped with Encrypt(), never in plain
+*/
+func GenerateDataKey() ([]byte, error) {
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+/*
+EncryptBytes
+- AES-GCM with the given key
+- Output is nonce || ciphertext
+*/
+func EncryptBytes(key, plain []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
 	gcm, err := cipher.NewGCM(block)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	nonceSize := gcm.NonceSize()
-	if len(data) < nonceSize {
-		return "", errors.New("invalid ciphertext")
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
 	}
 
-	nonce, cipherText := data[:nonceSize], data[nonceSize:]
-	plain, err := gcm.Open(nil, nonce, cipherText, nil)
+	return gcm.Seal(nonce, nonce, plain, nil), nil
+}
+
+/*
+DecryptBytes
+- Reverses EncryptBytes()
+*/
+func DecryptBytes(key, data []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	return string(plain), nil
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	nonceSize := gcm.NonceSize()
+	if len(data) < nonceSize {
+		return nil, errors.New("invalid ciphertext")
+	}
+
+	nonce, cipherText := data[:nonceSize], data[nonceSize:]
+	return gcm.Open(nil, nonce, cipherText, nil)
 }
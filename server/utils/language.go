@@ -0,0 +1,101 @@
+package utils
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// scripts that map to a single language well enough for comment rules
+var scriptLanguages = []struct {
+	table *unicode.RangeTable
+	lang  string
+}{
+	{unicode.Hangul, "ko"},
+	{unicode.Hiragana, "ja"},
+	{unicode.Katakana, "ja"},
+	{unicode.Han, "zh"},
+	{unicode.Cyrillic, "ru"},
+	{unicode.Arabic, "ar"},
+	{unicode.Hebrew, "he"},
+	{unicode.Greek, "el"},
+	{unicode.Devanagari, "hi"},
+	{unicode.Bengali, "bn"},
+	{unicode.Tamil, "ta"},
+	{unicode.Thai, "th"},
+}
+
+// common words of latin script languages
+var stopwords = map[string][]string{
+	"en": {"the", "and", "is", "you", "this", "that", "it", "to", "of", "for", "was", "are", "with", "what", "my", "your"},
+	"es": {"el", "la", "que", "de", "y", "es", "en", "los", "por", "para", "una", "muy", "pero", "como", "mi", "tu"},
+	"pt": {"o", "que", "de", "e", "é", "não", "um", "uma", "para", "com", "muito", "isso", "você", "mas", "meu", "os"},
+	"fr": {"le", "la", "les", "et", "est", "que", "de", "un", "une", "pour", "pas", "je", "vous", "c'est", "très", "mais"},
+	"de": {"der", "die", "und", "ist", "das", "nicht", "ich", "du", "ein", "eine", "mit", "auf", "sehr", "aber", "wie", "zu"},
+	"it": {"il", "che", "di", "e", "è", "un", "una", "per", "non", "sono", "molto", "ma", "come", "mi", "questo", "gli"},
+	"id": {"yang", "dan", "ini", "itu", "di", "ke", "saya", "tidak", "ada", "untuk", "dengan", "bang", "juga", "aku", "kak", "sangat"},
+}
+
+/*
+DetectLanguage
+- Guesses an ISO 639-1 code from the script, then from stopwords for latin text
+- Returns "" when the text is too short or gives nothing to go on
+*/
+func DetectLanguage(text string) string {
+	counts := map[string]int{}
+	letters := 0
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		for _, s := range scriptLanguages {
+			if unicode.Is(s.table, r) {
+				counts[s.lang]++
+				break
+			}
+		}
+	}
+
+	// kana anywhere means japanese even when kanji dominate
+	if counts["ja"] > 0 {
+		return "ja"
+	}
+
+	best, bestCount := "", 0
+	for lang, n := range counts {
+		if n > bestCount {
+			best, bestCount = lang, n
+		}
+	}
+	if bestCount*2 > letters {
+		return best
+	}
+
+	return detectLatin(text)
+}
+
+func detectLatin(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	})
+	if len(words) < 2 {
+		return ""
+	}
+
+	best, bestHits := "", 0
+	for lang, list := range stopwords {
+		hits := 0
+		for _, w := range words {
+			if slices.Contains(list, w) {
+				hits++
+			}
+		}
+		// ties go to the alphabetically first code so results are stable
+		if hits > bestHits || (hits == bestHits && hits > 0 && lang < best) {
+			best, bestHits = lang, hits
+		}
+	}
+
+	return best
+}
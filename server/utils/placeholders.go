@@ -0,0 +1,22 @@
+package utils
+
+import (
+	"regexp"
+)
+
+var placeholderRe = regexp.MustCompile(`\{\{\s*(\w+)\s*\}\}`)
+
+/*
+FillPlaceholders
+- Replaces {{name}} with vars["name"]
+- Unknown placeholders are left untouched so they stay visible to the user
+*/
+func FillPlaceholders(text string, vars map[string]string) string {
+	return placeholderRe.ReplaceAllStringFunc(text, func(match string) string {
+		name := placeholderRe.FindStringSubmatch(match)[1]
+		if v, ok := vars[name]; ok {
+			return v
+		}
+		return match
+	})
+}
@@ -0,0 +1,21 @@
+package utils
+
+import (
+	"time"
+)
+
+// RateLimiter hands out at most one slot per interval, callers block in Wait
+type RateLimiter struct {
+	ticker *time.Ticker
+}
+
+func NewRateLimiter(perSecond int) *RateLimiter {
+	if perSecond <= 0 {
+		perSecond = 1
+	}
+	return &RateLimiter{ticker: time.NewTicker(time.Second / time.Duration(perSecond))}
+}
+
+func (r *RateLimiter) Wait() {
+	<-r.ticker.C
+}
@@ -0,0 +1,156 @@
+package utils
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+const (
+	SentimentPositive = "positive"
+	SentimentNeutral  = "neutral"
+	SentimentNegative = "negative"
+
+	// scores beyond this either way get a label other than neutral
+	sentimentCutoff = 0.2
+	// how many following words a negation flips
+	negationWindow = 3
+)
+
+// word valences from -4 to 4, a language is supported by adding its lexicon
+// and negations, unknown languages fall back to english
+var sentimentLexicons = map[string]map[string]float64{
+	"en": {
+		"love": 3, "loved": 3, "loving": 2.5, "awesome": 3.1, "amazing": 2.8, "great": 3.1, "good": 1.9,
+		"nice": 1.8, "cool": 1.3, "best": 3.2, "excellent": 2.7, "fantastic": 2.6, "perfect": 2.7,
+		"helpful": 1.9, "useful": 1.9, "thanks": 1.9, "thank": 1.5, "beautiful": 2.9, "brilliant": 2.8,
+		"enjoyed": 2.2, "enjoy": 2.2, "fun": 2.3, "funny": 1.9, "like": 1.5, "liked": 1.8, "wow": 2.8,
+		"incredible": 2.6, "wonderful": 2.7, "glad": 2, "happy": 2.7, "favorite": 2, "legend": 2,
+		"underrated": 1.5, "clear": 1.2, "informative": 1.8, "inspiring": 2.2, "masterpiece": 3,
+		"bad": -2.5, "terrible": -2.1, "awful": -2, "worst": -3.1, "hate": -2.7, "hated": -3.2,
+		"boring": -1.3, "stupid": -2.4, "dumb": -2.3, "useless": -1.8, "waste": -1.8, "trash": -2.2,
+		"garbage": -2.4, "sucks": -1.5, "disappointed": -1.9, "disappointing": -2.2, "annoying": -1.7,
+		"wrong": -2.1, "misleading": -1.7, "clickbait": -2, "fake": -2.1, "scam": -2.6, "broken": -1.5,
+		"poor": -2.1, "horrible": -2.5, "sad": -2.1, "angry": -2.3, "confusing": -1.3, "unwatchable": -2.5,
+		"cringe": -1.8, "lame": -1.6, "ugly": -2.3, "unfortunately": -1.4, "problem": -1.7, "issue": -1,
+	},
+	"es": {
+		"amor": 3, "encanta": 3, "encantó": 3, "genial": 3, "excelente": 2.7, "bueno": 1.9, "buena": 1.9,
+		"increíble": 2.6, "gracias": 1.9, "mejor": 2, "hermoso": 2.9, "útil": 1.9, "divertido": 2.3,
+		"malo": -2.5, "mala": -2.5, "peor": -3.1, "odio": -2.7, "aburrido": -1.3, "basura": -2.4,
+		"horrible": -2.5, "estafa": -2.6, "falso": -2.1, "triste": -2.1, "decepción": -2,
+	},
+	"pt": {
+		"amei": 3, "amo": 3, "incrível": 2.6, "excelente": 2.7, "bom": 1.9, "boa": 1.9, "ótimo": 3,
+		"obrigado": 1.9, "obrigada": 1.9, "melhor": 2, "lindo": 2.9, "útil": 1.9, "top": 2,
+		"ruim": -2.5, "péssimo": -3, "pior": -3.1, "odeio": -2.7, "chato": -1.3, "lixo": -2.4,
+		"horrível": -2.5, "golpe": -2.6, "falso": -2.1, "triste": -2.1,
+	},
+	"fr": {
+		"adore": 3, "génial": 3, "excellent": 2.7, "bon": 1.9, "bonne": 1.9, "super": 2.5, "merci": 1.9,
+		"meilleur": 2, "magnifique": 2.9, "utile": 1.9, "incroyable": 2.6, "bravo": 2.5,
+		"mauvais": -2.5, "nul": -2.4, "pire": -3.1, "déteste": -2.7, "ennuyeux": -1.3, "horrible": -2.5,
+		"arnaque": -2.6, "faux": -2.1, "triste": -2.1, "déçu": -1.9,
+	},
+	"de": {
+		"liebe": 3, "toll": 2.8, "super": 2.5, "gut": 1.9, "klasse": 2.5, "danke": 1.9, "genial": 3,
+		"beste": 3.2, "schön": 2.5, "hilfreich": 1.9, "nützlich": 1.9, "perfekt": 2.7,
+		"schlecht": -2.5, "schlimm": -2.1, "schlechteste": -3.1, "hasse": -2.7, "langweilig": -1.3,
+		"müll": -2.4, "schrecklich": -2.5, "betrug": -2.6, "traurig": -2.1, "enttäuscht": -1.9,
+	},
+}
+
+var negations = map[string]map[string]bool{
+	"en": {"not": true, "no": true, "never": true, "dont": true, "don't": true, "isnt": true, "isn't": true,
+		"wasnt": true, "wasn't": true, "cant": true, "can't": true, "didnt": true, "didn't": true, "nothing": true},
+	"es": {"no": true, "nunca": true, "nada": true, "ni": true},
+	"pt": {"não": true, "nao": true, "nunca": true, "nada": true, "nem": true},
+	"fr": {"pas": true, "jamais": true, "rien": true, "ne": true},
+	"de": {"nicht": true, "kein": true, "keine": true, "nie": true, "niemals": true},
+}
+
+var intensifiers = map[string]float64{
+	"very": 1.3, "really": 1.3, "so": 1.3, "super": 1.3, "extremely": 1.5, "absolutely": 1.4, "too": 1.2,
+	"muy": 1.3, "muito": 1.3, "très": 1.3, "sehr": 1.3,
+}
+
+var emojiValence = map[rune]float64{
+	'😀': 2, '😃': 2, '😄': 2, '😁': 2, '😆': 2, '😂': 1.5, '🤣': 1.5, '😊': 2, '🙂': 1, '😍': 3,
+	'🥰': 3, '😘': 2.5, '🤩': 3, '👍': 2, '👏': 2, '🙌': 2, '💯': 2, '🔥': 2, '❤': 3, '💕': 3,
+	'💖': 3, '💙': 2.5, '💜': 2.5, '💚': 2.5, '✨': 1, '🎉': 2, '🙏': 1.5, '😎': 1.5, '🥳': 2.5,
+	'😢': -2, '😭': -1.5, '😞': -2, '😔': -1.5, '😡': -3, '😠': -2.5, '🤬': -3, '👎': -2, '💩': -2,
+	'🤮': -3, '🤢': -2.5, '😒': -1.5, '🙄': -1.5, '😤': -1.5, '💔': -2.5, '😴': -1, '🥱': -1.5,
+}
+
+type Sentiment struct {
+	Score float64 `json:"score"` // -1 to 1
+	Label string  `json:"label"`
+}
+
+/*
+AnalyzeSentiment
+- Sums word and emoji valences, the lexicon is picked with DetectLanguage
+- A negation flips the next few words, intensifiers scale the next one, "!" adds emphasis
+- The sum is squashed into -1..1 like VADER's compound score
+*/
+func AnalyzeSentiment(text string) Sentiment {
+	lang := DetectLanguage(text)
+	lexicon, ok := sentimentLexicons[lang]
+	if !ok {
+		lang, lexicon = "en", sentimentLexicons["en"]
+	}
+
+	sum := 0.0
+	for _, r := range text {
+		sum += emojiValence[r]
+	}
+
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	})
+
+	negatedFor := 0
+	boost := 1.0
+	for _, w := range words {
+		if negations[lang][w] {
+			negatedFor = negationWindow
+			continue
+		}
+		// "super" is praise on its own in French and German, the lexicon wins
+		v, scored := lexicon[w]
+		if f, ok := intensifiers[w]; ok && !scored {
+			boost = f
+			continue
+		}
+
+		if scored {
+			v *= boost
+			if negatedFor > 0 {
+				// "not bad" is mildly positive rather than as good as "good"
+				v *= -0.74
+			}
+			sum += v
+		}
+
+		boost = 1
+		if negatedFor > 0 {
+			negatedFor--
+		}
+	}
+
+	if sum != 0 {
+		emphasis := float64(min(strings.Count(text, "!"), 4)) * 0.29
+		sum += math.Copysign(emphasis, sum)
+	}
+
+	score := math.Round(sum/math.Sqrt(sum*sum+15)*100) / 100
+
+	label := SentimentNeutral
+	if score >= sentimentCutoff {
+		label = SentimentPositive
+	} else if score <= -sentimentCutoff {
+		label = SentimentNegative
+	}
+
+	return Sentiment{Score: score, Label: label}
+}
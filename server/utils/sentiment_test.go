@@ -0,0 +1,34 @@
+package utils
+
+import "testing"
+
+func TestAnalyzeSentiment(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		score float64
+		label string
+	}{
+		{name: "empty", text: "", score: 0, label: SentimentNeutral},
+		{name: "no sentiment words", text: "the video is here", score: 0, label: SentimentNeutral},
+		{name: "positive word", text: "good", score: 0.44, label: SentimentPositive},
+		{name: "negative word", text: "bad", score: -0.54, label: SentimentNegative},
+		{name: "negation flips and dampens", text: "not good", score: -0.34, label: SentimentNegative},
+		{name: "negation only reaches a few words", text: "not that it matters, good", score: 0.44, label: SentimentPositive},
+		{name: "intensifier", text: "very good", score: 0.54, label: SentimentPositive},
+		{name: "super intensifies in english", text: "super good", score: 0.54, label: SentimentPositive},
+		{name: "exclamation marks add emphasis", text: "good!!", score: 0.54, label: SentimentPositive},
+		{name: "emoji", text: "👍", score: 0.46, label: SentimentPositive},
+		{name: "french super is praise", text: "C'est une super vidéo, je suis content", score: 0.54, label: SentimentPositive},
+		{name: "german super is praise", text: "Das ist ein super Video, ich bin froh", score: 0.54, label: SentimentPositive},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := AnalyzeSentiment(tt.text)
+			if got.Score != tt.score || got.Label != tt.label {
+				t.Errorf("AnalyzeSentiment(%q) = %+v, want {Score:%v Label:%s}", tt.text, got, tt.score, tt.label)
+			}
+		})
+	}
+}
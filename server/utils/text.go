@@ -0,0 +1,164 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+)
+
+// look-alikes impersonators swap into names
+var homoglyphs = map[rune]rune{
+	'0': 'o',
+	'1': 'l',
+	'3': 'e',
+	'4': 'a',
+	'5': 's',
+	'7': 't',
+	'@': 'a',
+	'$': 's',
+	'|': 'l',
+	'а': 'a', // cyrillic
+	'е': 'e',
+	'о': 'o',
+	'р': 'p',
+	'с': 'c',
+	'х': 'x',
+	'і': 'i',
+}
+
+/*
+NormalizeText
+- Lowercases, folds common look-alike characters and drops everything but letters and digits
+- Meant for comparing texts and names, not for display
+*/
+func NormalizeText(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if h, ok := homoglyphs[r]; ok {
+			r = h
+		}
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// Similarity is 1 minus the edit distance over the longer length, 1 means equal
+func Similarity(a string, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := max(len(ra), len(rb))
+	if longest == 0 {
+		return 1
+	}
+
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return 1 - float64(prev[len(rb)])/float64(longest)
+}
+
+// IsEmoji covers the pictographic blocks, good enough for counting
+func IsEmoji(r rune) bool {
+	return (r >= 0x1F300 && r <= 0x1FAFF) ||
+		(r >= 0x2600 && r <= 0x27BF) ||
+		(r >= 0x1F1E6 && r <= 0x1F1FF)
+}
+
+// CountEmoji returns the number of emoji and of non space runes in s
+func CountEmoji(s string) (int, int) {
+	emoji, total := 0, 0
+	for _, r := range s {
+		if unicode.IsSpace(r) || r == 0xFE0F || r == 0x200D {
+			continue
+		}
+		total++
+		if IsEmoji(r) {
+			emoji++
+		}
+	}
+	return emoji, total
+}
+
+// filler words that carry no topic, on top of the language stopwords
+var extraStopwords = []string{
+	"a", "an", "i", "me", "we", "in", "on", "at", "be", "am", "do", "does", "did",
+	"can", "could", "would", "should", "will", "how", "why", "when", "where", "who", "which",
+	"there", "any", "anyone", "have", "has", "had", "so", "if", "or", "not", "no", "just",
+	"about", "from", "as", "by", "they", "he", "she", "them", "its", "im", "dont", "get",
+	"got", "also", "much", "pls", "please", "hi", "hey", "u", "ur",
+}
+
+var stopwordSet = func() map[string]bool {
+	set := map[string]bool{}
+	for _, list := range stopwords {
+		for _, w := range list {
+			set[w] = true
+		}
+	}
+	for _, w := range extraStopwords {
+		set[w] = true
+	}
+	return set
+}()
+
+// Tokenize lowercases text into words, dropping stopwords and single letters
+func Tokenize(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	tokens := make([]string, 0, len(words))
+	for _, w := range words {
+		if len([]rune(w)) > 1 && !stopwordSet[w] {
+			tokens = append(tokens, w)
+		}
+	}
+	return tokens
+}
+
+// words that open a question without a question mark, in the languages DetectLanguage knows
+var questionOpeners = map[string]bool{
+	"what": true, "how": true, "why": true, "when": true, "where": true, "who": true, "which": true,
+	"can": true, "could": true, "is": true, "are": true, "do": true, "does": true, "did": true,
+	"will": true, "would": true, "should": true, "anyone": true,
+	"qué": true, "cómo": true, "cuándo": true, "dónde": true, "cuál": true,
+	"quién": true, "quando": true, "onde": true, "qual": true,
+	"pourquoi": true, "quand": true, "où": true, "quel": true, "quelle": true, "est-ce": true,
+	"wie": true, "warum": true, "wann": true, "wo": true, "wer": true, "welche": true,
+	"cosa": true, "perché": true, "dove": true, "chi": true,
+	"apa": true, "bagaimana": true, "kenapa": true, "kapan": true, "dimana": true,
+}
+
+/*
+IsQuestion
+- A question mark (any script's) anywhere in the text
+- Or a sentence opening with a question word, "how do I ..." without punctuation is common in comments
+*/
+func IsQuestion(text string) bool {
+	if strings.ContainsAny(text, "?？¿؟") {
+		return true
+	}
+
+	for _, sentence := range strings.FieldsFunc(text, func(r rune) bool { return r == '.' || r == '!' || r == '\n' }) {
+		words := strings.Fields(strings.ToLower(sentence))
+		if len(words) >= 3 && questionOpeners[strings.Trim(words[0], ",;:")] {
+			return true
+		}
+	}
+	return false
+}
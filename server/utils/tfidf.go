@@ -0,0 +1,142 @@
+package utils
+
+import (
+	"math"
+	"sort"
+)
+
+// TextCluster is a group of similar documents, indexes point into the input
+type TextCluster struct {
+	Members        []int
+	Representative int      // the member closest to the centroid
+	Terms          []string // highest weighted terms of the centroid
+}
+
+type sparseVector map[string]float64
+
+/*
+ClusterTexts
+- Builds TF-IDF vectors from tokenized documents and groups them greedily
+- A document joins the most similar cluster when the cosine similarity to its centroid reaches threshold, otherwise it starts a new one
+- Clusters come back largest first
+*/
+func ClusterTexts(docs [][]string, threshold float64) []TextCluster {
+	df := map[string]int{}
+	for _, doc := range docs {
+		seen := map[string]bool{}
+		for _, t := range doc {
+			if !seen[t] {
+				seen[t] = true
+				df[t]++
+			}
+		}
+	}
+
+	vectors := make([]sparseVector, len(docs))
+	for i, doc := range docs {
+		tf := map[string]float64{}
+		for _, t := range doc {
+			tf[t]++
+		}
+
+		v := sparseVector{}
+		for t, n := range tf {
+			// smoothed idf so terms in every document still count a little
+			v[t] = n * (math.Log(float64(1+len(docs))/float64(1+df[t])) + 1)
+		}
+		vectors[i] = v.normalized()
+	}
+
+	type cluster struct {
+		members  []int
+		sum      sparseVector
+		centroid sparseVector
+	}
+	clusters := []*cluster{}
+
+	for i, v := range vectors {
+		if len(v) == 0 {
+			continue
+		}
+
+		var best *cluster
+		bestSim := threshold
+		for _, c := range clusters {
+			if sim := v.dot(c.centroid); sim >= bestSim {
+				best, bestSim = c, sim
+			}
+		}
+
+		if best == nil {
+			best = &cluster{sum: sparseVector{}}
+			clusters = append(clusters, best)
+		}
+		best.members = append(best.members, i)
+		for t, w := range v {
+			best.sum[t] += w
+		}
+		best.centroid = best.sum.normalized()
+	}
+
+	result := make([]TextCluster, 0, len(clusters))
+	for _, c := range clusters {
+		centroid := c.centroid
+
+		rep, repSim := c.members[0], -1.0
+		for _, m := range c.members {
+			if sim := vectors[m].dot(centroid); sim > repSim {
+				rep, repSim = m, sim
+			}
+		}
+
+		result = append(result, TextCluster{
+			Members:        c.members,
+			Representative: rep,
+			Terms:          centroid.top(5),
+		})
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return len(result[i].Members) > len(result[j].Members)
+	})
+	return result
+}
+
+func (v sparseVector) normalized() sparseVector {
+	norm := 0.0
+	for _, w := range v {
+		norm += w * w
+	}
+	norm = math.Sqrt(norm)
+
+	out := make(sparseVector, len(v))
+	for t, w := range v {
+		out[t] = w / norm
+	}
+	return out
+}
+
+func (v sparseVector) dot(o sparseVector) float64 {
+	if len(o) < len(v) {
+		v, o = o, v
+	}
+	sum := 0.0
+	for t, w := range v {
+		sum += w * o[t]
+	}
+	return sum
+}
+
+func (v sparseVector) top(n int) []string {
+	terms := make([]string, 0, len(v))
+	for t := range v {
+		terms = append(terms, t)
+	}
+	sort.Slice(terms, func(i, j int) bool {
+		if v[terms[i]] != v[terms[j]] {
+			return v[terms[i]] > v[terms[j]]
+		}
+		return terms[i] < terms[j]
+	})
+	return terms[:min(n, len(terms))]
+}
@@ -0,0 +1,96 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestClusterTexts(t *testing.T) {
+	tests := []struct {
+		name      string
+		docs      [][]string
+		threshold float64
+		members   [][]int
+	}{
+		{
+			name:      "no documents",
+			docs:      nil,
+			threshold: 0.3,
+			members:   [][]int{},
+		},
+		{
+			name: "same question asked twice",
+			docs: [][]string{
+				{"camera", "model"},
+				{"camera", "model"},
+			},
+			threshold: 0.3,
+			members:   [][]int{{0, 1}},
+		},
+		{
+			name: "unrelated questions stay apart, largest cluster first",
+			docs: [][]string{
+				{"song", "name"},
+				{"camera", "model"},
+				{"camera", "model", "used"},
+				{"camera", "model"},
+			},
+			threshold: 0.3,
+			members:   [][]int{{1, 2, 3}, {0}},
+		},
+		{
+			name: "documents without tokens are skipped",
+			docs: [][]string{
+				{},
+				{"song", "name"},
+				{},
+				{"song", "name"},
+			},
+			threshold: 0.3,
+			members:   [][]int{{1, 3}},
+		},
+		{
+			name: "a strict threshold only joins identical documents",
+			docs: [][]string{
+				{"camera", "model"},
+				{"camera", "model", "used"},
+				{"camera", "model"},
+			},
+			threshold: 0.99,
+			members:   [][]int{{0, 2}, {1}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := [][]int{}
+			for _, c := range ClusterTexts(tt.docs, tt.threshold) {
+				got = append(got, c.Members)
+			}
+			if !reflect.DeepEqual(got, tt.members) {
+				t.Errorf("members = %v, want %v", got, tt.members)
+			}
+		})
+	}
+}
+
+func TestClusterTextsRepresentativeAndTerms(t *testing.T) {
+	docs := [][]string{
+		{"camera", "model", "lens"},
+		{"camera", "model"},
+		{"camera", "model", "mic"},
+	}
+
+	clusters := ClusterTexts(docs, 0.3)
+	if len(clusters) != 1 {
+		t.Fatalf("got %d clusters, want 1", len(clusters))
+	}
+
+	// the shared terms make up the centroid, the doc with nothing else is closest
+	if clusters[0].Representative != 1 {
+		t.Errorf("representative = %d, want 1", clusters[0].Representative)
+	}
+	if want := []string{"camera", "model"}; !reflect.DeepEqual(clusters[0].Terms[:2], want) {
+		t.Errorf("terms = %v, want %v first", clusters[0].Terms, want)
+	}
+}